@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/lgdzz/vingo-utils-v3/db"
+	"github.com/lgdzz/vingo-utils-v3/db/book"
+	"github.com/lgdzz/vingo-utils-v3/db/fixture"
 	"github.com/lgdzz/vingo-utils-v3/vingo"
 )
 
@@ -28,11 +30,17 @@ func InitCli(options Options) {
 	}
 	model := flag.String("m", "", "生成数据库模型，支持多个表生成，格式：table1,table2")
 
+	er := flag.String("er", "", "生成ER图文件，按扩展名输出格式：.md=markdown;.dot=graphviz;其他=mermaid")
+	erPrefix := flag.String("er-prefix", "", "ER图只包含指定前缀的表，支持多个，格式：sys_,biz_")
+
 	buildDev := flag.String("build-dev", "", "打包开发版，参数：l=linux;w=windows;m=mac;l_arm=linux arm")
 	buildProd := flag.String("build-prod", "", "打包正式版，参数：l=linux;w=windows;m=mac;l_arm=linux arm")
 
 	updateVingo := flag.String("v3", "", "更新vingo-v3版本")
 
+	seed := flag.String("seed", "", "写入YAML填充数据，支持多个文件或目录，格式：fixtures/demo,fixtures/user.yml")
+	reset := flag.String("reset", "", "清空填充数据涉及的表后重新写入，格式同seed")
+
 	if options.Register != nil {
 		options.Register()
 	}
@@ -54,6 +62,22 @@ func InitCli(options Options) {
 		os.Exit(0)
 	}
 
+	// 生成ER图
+	if *er != "" {
+		BuildEr(options.DatabaseApi, *er, *erPrefix)
+		os.Exit(0)
+	}
+
+	// 填充数据，模型需在 Register 中通过 fixture.Register 注册
+	if *seed != "" {
+		fixture.Seed(options.DatabaseApi, strings.Split(*seed, ",")...)
+		os.Exit(0)
+	}
+	if *reset != "" {
+		fixture.Reset(options.DatabaseApi, strings.Split(*reset, ",")...)
+		os.Exit(0)
+	}
+
 	if *buildDev != "" {
 		BuildProject(*buildDev, "dev")
 	}
@@ -69,6 +93,28 @@ func InitCli(options Options) {
 
 }
 
+// BuildEr 生成ER图文件
+func BuildEr(api *db.Api, file string, prefix string) {
+	option := book.ErOption{Format: book.ErMermaid}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md":
+		option.Format = book.ErMarkdown
+	case ".dot", ".gv":
+		option.Format = book.ErDot
+	}
+	if prefix != "" {
+		option.Prefix = strings.Split(prefix, ",")
+	}
+	if dir := filepath.Dir(file); dir != "." {
+		_ = os.MkdirAll(dir, 0777)
+	}
+	if err := os.WriteFile(file, []byte(api.Er(option)), 0644); err != nil {
+		log.Println("写入ER图文件错误：", err.Error())
+		return
+	}
+	log.Println("✅ ER图已生成:", file)
+}
+
 func BuildProject(value string, version string) {
 	var goos string
 	var osName string
@@ -0,0 +1,99 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：密文字段盲索引
+//
+// Ciphertext 每次加密结果不同，无法直接按值查询，盲索引对明文做 HMAC-SHA256 后存入单独的索引列用于精确匹配。
+// 索引列通过 blind 标签声明来源字段和归一化方式，创建和更新时由 db 插件自动计算：
+//
+//	Phone      ctype.Ciphertext `gorm:"column:phone"`
+//	PhoneIndex string           `gorm:"column:phone_bidx;size:64;index" blind:"Phone"`
+//	PhoneLast4 string           `gorm:"column:phone_last4;size:64;index" blind:"Phone,last4"`
+//
+// 查询：api.QueryWhereBlind(tx, input.Phone, "phone_bidx") / api.QueryWhereBlind(tx, "1234", "phone_last4", "last4")
+// 注意：BlindKey 变更后需重新计算全部索引，请勿与 Secret 一同轮换；数据库连接单独配置的 blindKey 见 ctype.KeySet
+// *****************************************************************************
+
+package ctype
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var BlindKey []byte // 盲索引key，为空时使用 Secret
+
+var blindNormalizers = sync.Map{}
+
+func init() {
+	RegisterBlindNormalizer("lower", func(value string) string {
+		return strings.ToLower(value)
+	})
+	RegisterBlindNormalizer("digits", func(value string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, value)
+	})
+}
+
+// RegisterBlindNormalizer 注册归一化方式，内置 lower、digits、lastN（如 last4，取末尾N位）
+func RegisterBlindNormalizer(name string, fn func(value string) string) {
+	blindNormalizers.Store(name, fn)
+}
+
+// BlindNormalize 按归一化方式处理明文，结果为空时不生成索引
+func BlindNormalize(value string, mode string) string {
+	value = strings.TrimSpace(value)
+	if mode == "" || value == "" {
+		return value
+	}
+	if fn, ok := blindNormalizers.Load(mode); ok {
+		return fn.(func(string) string)(value)
+	}
+	if strings.HasPrefix(mode, "last") {
+		n, err := strconv.Atoi(strings.TrimPrefix(mode, "last"))
+		if err == nil && n > 0 {
+			runes := []rune(value)
+			if len(runes) < n {
+				return ""
+			}
+			return string(runes[len(runes)-n:])
+		}
+	}
+	panic(fmt.Sprintf("盲索引归一化方式不存在：%v", mode))
+}
+
+// BlindIndex 计算盲索引，明文为空时返回空
+// 不同归一化方式的索引互不相同，避免末尾N位索引与完整值索引相互碰撞
+func BlindIndex(value string, mode ...string) string {
+	key := BlindKey
+	if len(key) == 0 {
+		key = Secret
+	}
+	return blindIndex(key, value, mode...)
+}
+
+func blindIndex(key []byte, value string, mode ...string) string {
+	var m string
+	if len(mode) > 0 {
+		m = mode[0]
+	}
+	value = BlindNormalize(value, m)
+	if value == "" {
+		return ""
+	}
+	if len(key) == 0 {
+		panic("未设置盲索引key")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(m + ":" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -7,16 +7,17 @@
 package ctype
 
 import (
+	"context"
 	"database/sql/driver"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
-	"github.com/duke-git/lancet/v2/cryptor"
 	local_cryptor "github.com/lgdzz/vingo-utils-v3/cryptor"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-var Secret []byte // 请确保为 16/24/32 字节的安全 key
+var Secret []byte // 请确保为 16/24/32 字节的安全 key，未配置密钥环时用于加密，配置后仅用于解密历史密文；数据库连接单独配置的key见 KeySet
 
 type Ciphertext string
 
@@ -26,9 +27,15 @@ func (s Ciphertext) Value() (driver.Value, error) {
 	if s == "" {
 		return "", nil
 	}
-	cipherBytes := cryptor.AesGcmEncrypt([]byte(s), Secret)
-	cipherText := base64.StdEncoding.EncodeToString(cipherBytes)
-	return []byte(cipherText), nil
+	return []byte(Encrypt(string(s))), nil
+}
+
+// GormValue 通过 gorm 写入时使用所在连接的key加密
+func (s Ciphertext) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	if s == "" {
+		return clause.Expr{SQL: "?", Vars: []any{""}}
+	}
+	return clause.Expr{SQL: "?", Vars: []any{[]byte(KeySetOf(db).Encrypt(string(s)))}}
 }
 
 func (s *Ciphertext) Scan(value any) error {
@@ -52,20 +59,13 @@ func (s *Ciphertext) Scan(value any) error {
 		return nil
 	}
 
-	cipherBytes, err := base64.StdEncoding.DecodeString(str)
+	// 解密失败返回错误，避免把无法解密的数据当作空值读出后再覆盖写回
+	plain, err := Decrypt(str)
 	if err != nil {
 		*s = ""
-		return nil
+		return fmt.Errorf("Ciphertext.Scan %w", err)
 	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			*s = ""
-		}
-	}()
-
-	plainBytes := cryptor.AesGcmDecrypt(cipherBytes, Secret)
-	*s = Ciphertext(plainBytes)
+	*s = Ciphertext(plain)
 	return nil
 }
 
@@ -0,0 +1,309 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：密文字段多版本密钥
+//
+// 密文格式：$密钥ID$base64密文，如 $k2$Jq8f...
+// 无前缀的密文为历史数据，使用 Secret 解密。
+// 写入时始终使用当前启用的密钥，其他密钥仅用于解密，配合 db/rekey 将历史数据重新加密后即可下线旧密钥。
+// *****************************************************************************
+
+package ctype
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/duke-git/lancet/v2/cryptor"
+	"gorm.io/gorm"
+)
+
+type keyring struct {
+	mu     sync.RWMutex
+	active string
+	keys   map[string][]byte
+}
+
+var ring = &keyring{keys: map[string][]byte{}}
+
+// SetKeyring 设置密钥环，active 为当前加密使用的密钥ID，keys 中其余密钥仅用于解密
+func SetKeyring(active string, keys map[string]string) {
+	if active != "" {
+		if _, ok := keys[active]; !ok {
+			panic(fmt.Sprintf("密钥环中不存在启用的密钥：%v", active))
+		}
+	}
+	list := map[string][]byte{}
+	for id, key := range keys {
+		checkKey(id, []byte(key))
+		list[id] = []byte(key)
+	}
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.active = active
+	ring.keys = list
+}
+
+// RegisterKey 添加密钥，已存在时覆盖
+func RegisterKey(id string, key []byte) {
+	checkKey(id, key)
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.keys[id] = key
+}
+
+// SetActiveKey 设置当前加密使用的密钥，密钥需已添加
+func SetActiveKey(id string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	if _, ok := ring.keys[id]; !ok {
+		panic(fmt.Sprintf("密钥环中不存在启用的密钥：%v", id))
+	}
+	ring.active = id
+}
+
+// ActiveKeyId 当前加密使用的密钥ID，为空表示使用 Secret 且不带前缀
+func ActiveKeyId() string {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.active
+}
+
+func checkKey(id string, key []byte) {
+	if id == "" || strings.Contains(id, "$") {
+		panic(fmt.Sprintf("密钥ID不合法：%v", id))
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		panic(fmt.Sprintf("密钥[%v]长度需为16/24/32字节", id))
+	}
+}
+
+func getKey(id string) ([]byte, error) {
+	if id == "" {
+		if len(Secret) == 0 {
+			return nil, errors.New("未设置密文字段key")
+		}
+		return Secret, nil
+	}
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	key, ok := ring.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("密钥不存在：%v", id)
+	}
+	return key, nil
+}
+
+// KeyIdOf 获取密文使用的密钥ID，历史密文返回空
+func KeyIdOf(text string) string {
+	id, _ := splitCipher(text)
+	return id
+}
+
+// NeedRekey 密文是否需要使用当前密钥重新加密
+func NeedRekey(text string) bool {
+	return text != "" && KeyIdOf(text) != ActiveKeyId()
+}
+
+func splitCipher(text string) (string, string) {
+	if !strings.HasPrefix(text, "$") {
+		return "", text
+	}
+	parts := strings.SplitN(text[1:], "$", 2)
+	if len(parts) != 2 {
+		return "", text
+	}
+	return parts[0], parts[1]
+}
+
+// Encrypt 使用当前密钥加密
+func Encrypt(plain string) string {
+	id := ActiveKeyId()
+	key, err := getKey(id)
+	if err != nil {
+		panic(err.Error())
+	}
+	text := base64.StdEncoding.EncodeToString(cryptor.AesGcmEncrypt([]byte(plain), key))
+	if id == "" {
+		return text
+	}
+	return fmt.Sprintf("$%v$%v", id, text)
+}
+
+// Decrypt 根据密文前缀选择密钥解密，依次尝试全局和各连接中该ID的key
+func Decrypt(text string) (plain string, err error) {
+	id, body := splitCipher(text)
+	keys := candidateKeys(id)
+	if len(keys) == 0 {
+		_, err = getKey(id)
+		return "", err
+	}
+	cipherBytes, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("密文格式错误: %w", err)
+	}
+	for _, key := range keys {
+		if plain, err = decrypt(cipherBytes, key); err == nil {
+			return
+		}
+	}
+	if id == "" {
+		id = "Secret"
+	}
+	return "", fmt.Errorf("密文解密失败，密钥：%v，原因：%w", id, err)
+}
+
+func decrypt(cipherBytes []byte, key []byte) (plain string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			plain = ""
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return string(cryptor.AesGcmDecrypt(cipherBytes, key)), nil
+}
+
+// KeySet 数据库连接的密文key，通过 db.Use 挂载到连接上，Ciphertext 写入时使用所在连接的key加密；
+// 未配置的部分使用全局配置（Secret、密钥环、BlindKey）。读取时依次尝试全局和各连接的key，AES-GCM 可以校验密钥是否匹配。
+type KeySet struct {
+	Secret   []byte
+	Active   string
+	Keys     map[string][]byte
+	BlindKey []byte
+}
+
+const keySetPlugin = "vingo:keyset"
+
+var (
+	keySetMu sync.RWMutex
+	keySets  []*KeySet
+)
+
+// NewKeySet 创建连接的密文key，active 需存在于 keys 中
+func NewKeySet(secret string, active string, keys map[string]string, blindKey string) *KeySet {
+	s := &KeySet{Active: active, Keys: map[string][]byte{}}
+	if secret != "" {
+		s.Secret = []byte(secret)
+	}
+	if blindKey != "" {
+		s.BlindKey = []byte(blindKey)
+	}
+	for id, key := range keys {
+		checkKey(id, []byte(key))
+		s.Keys[id] = []byte(key)
+	}
+	if active != "" {
+		if _, ok := s.Keys[active]; !ok {
+			panic(fmt.Sprintf("密钥环中不存在启用的密钥：%v", active))
+		}
+	}
+	return s
+}
+
+func (s *KeySet) Name() string {
+	return keySetPlugin
+}
+
+// Initialize 挂载到连接时登记，用于解密其他连接写入的密文
+func (s *KeySet) Initialize(*gorm.DB) error {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	keySets = append(keySets, s)
+	return nil
+}
+
+// KeySetOf 连接的密文key，未挂载时返回 nil，nil 的方法均使用全局配置
+func KeySetOf(db *gorm.DB) *KeySet {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+	if plugin, ok := db.Config.Plugins[keySetPlugin]; ok {
+		return plugin.(*KeySet)
+	}
+	return nil
+}
+
+// ActiveKeyId 当前加密使用的密钥ID
+func (s *KeySet) ActiveKeyId() string {
+	if s == nil || (s.Active == "" && len(s.Secret) == 0) {
+		return ActiveKeyId()
+	}
+	return s.Active
+}
+
+// NeedRekey 密文是否需要使用当前密钥重新加密
+func (s *KeySet) NeedRekey(text string) bool {
+	return text != "" && KeyIdOf(text) != s.ActiveKeyId()
+}
+
+// Encrypt 使用当前密钥加密
+func (s *KeySet) Encrypt(plain string) string {
+	if s == nil || (s.Active == "" && len(s.Secret) == 0) {
+		return Encrypt(plain)
+	}
+	if s.Active == "" {
+		return base64.StdEncoding.EncodeToString(cryptor.AesGcmEncrypt([]byte(plain), s.Secret))
+	}
+	text := base64.StdEncoding.EncodeToString(cryptor.AesGcmEncrypt([]byte(plain), s.Keys[s.Active]))
+	return fmt.Sprintf("$%v$%v", s.Active, text)
+}
+
+// Decrypt 优先使用连接的key解密，失败时按 Decrypt 依次尝试其他key
+func (s *KeySet) Decrypt(text string) (string, error) {
+	if s != nil {
+		id, body := splitCipher(text)
+		if key := s.key(id); key != nil {
+			if cipherBytes, err := base64.StdEncoding.DecodeString(body); err == nil {
+				if plain, err := decrypt(cipherBytes, key); err == nil {
+					return plain, nil
+				}
+			}
+		}
+	}
+	return Decrypt(text)
+}
+
+// BlindIndex 计算盲索引，依次使用连接的 BlindKey、Secret，未配置时使用全局配置
+func (s *KeySet) BlindIndex(value string, mode ...string) string {
+	if s != nil {
+		if len(s.BlindKey) > 0 {
+			return blindIndex(s.BlindKey, value, mode...)
+		}
+		if len(s.Secret) > 0 {
+			return blindIndex(s.Secret, value, mode...)
+		}
+	}
+	return BlindIndex(value, mode...)
+}
+
+func (s *KeySet) key(id string) []byte {
+	if id == "" {
+		return s.Secret
+	}
+	return s.Keys[id]
+}
+
+// candidateKeys 可用于解密的key，全局配置在前，重复的key只保留一个
+func candidateKeys(id string) [][]byte {
+	var keys [][]byte
+	if key, err := getKey(id); err == nil {
+		keys = append(keys, key)
+	}
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	for _, s := range keySets {
+		key := s.key(id)
+		if len(key) == 0 || slices.ContainsFunc(keys, func(k []byte) bool { return bytes.Equal(k, key) }) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -97,10 +97,17 @@ func (s *Password) ReplacePassword(raw string, level int, isTemp bool) Password
 
 // checkPasswordLevel 密码强度检查
 func checkPasswordLevel(raw string, level int) {
+	if message := passwordLevelError(raw, level); message != "" {
+		panic(message)
+	}
+}
+
+// passwordLevelError 密码长度和强度不符合要求时返回提示
+func passwordLevelError(raw string, level int) string {
 	n := len(raw)
 
 	if n < MinPasswordLen || n > MaxPasswordLen {
-		panic(fmt.Sprintf("密码长度需符合 %d-%d 个字符长度要求", MinPasswordLen, MaxPasswordLen))
+		return fmt.Sprintf("密码长度需符合 %d-%d 个字符长度要求", MinPasswordLen, MaxPasswordLen)
 	}
 
 	hasDigit, hasUpper, hasLower, hasSpecial := analyzeChars(raw)
@@ -108,13 +115,14 @@ func checkPasswordLevel(raw string, level int) {
 	switch level {
 	case PasswordMedium:
 		if countTrue(hasDigit, hasUpper, hasLower, hasSpecial) < 2 {
-			panic("密码需满足至少两种字符组合（数字、大写字母、小写字母、特殊符号）")
+			return "密码需满足至少两种字符组合（数字、大写字母、小写字母、特殊符号）"
 		}
 	case PasswordStrong:
 		if !(hasDigit && hasUpper && hasLower && hasSpecial) {
-			panic("密码需满足四种字符组合（数字、大写字母、小写字母、特殊符号）")
+			return "密码需满足四种字符组合（数字、大写字母、小写字母、特殊符号）"
 		}
 	}
+	return ""
 }
 
 // EnableTotp 启用totp
@@ -0,0 +1,126 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：自定义类型的校验规则，binding 和 validate 标签均可使用
+//
+//	phone              手机号
+//	idcard             身份证号
+//	money              金额，最多两位小数；money=0~10000 同时限制范围，可省略一端，如 money=0~
+//	password=2         密码长度和强度，参数为 PasswordWeak|PasswordMedium|PasswordStrong，默认 PasswordWeak
+// *****************************************************************************
+
+package ctype
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+func init() {
+	vingo.RegisterValidation("phone", validatePhone, map[string]string{
+		"zh": "{0}格式不正确",
+		"en": "{0} must be a valid phone number",
+	})
+	vingo.RegisterValidation("idcard", validateIdCard, map[string]string{
+		"zh": "{0}格式不正确",
+		"en": "{0} must be a valid ID card number",
+	})
+	vingo.RegisterValidationFunc("money", validateMoney, func(locale string, fe validator.FieldError) string {
+		if locale == "en" {
+			if fe.Param() != "" {
+				return fe.Field() + " must be an amount with at most 2 decimal places, range " + fe.Param()
+			}
+			return fe.Field() + " must be an amount with at most 2 decimal places"
+		}
+		if fe.Param() != "" {
+			return fe.Field() + "最多两位小数，范围" + fe.Param()
+		}
+		return fe.Field() + "最多两位小数"
+	})
+	vingo.RegisterValidation("password", validatePassword, map[string]string{
+		"zh": "{0}长度需为6-25个字符，且符合强度要求",
+		"en": "{0} must be 6-25 characters and meet the strength requirement",
+	})
+}
+
+func stringValue(fl validator.FieldLevel) (string, bool) {
+	if fl.Field().Kind() != reflect.String {
+		return "", false
+	}
+	return strings.TrimSpace(fl.Field().String()), true
+}
+
+// validatePhone 空值交给 required 判断
+func validatePhone(fl validator.FieldLevel) bool {
+	value, ok := stringValue(fl)
+	return ok && (value == "" || Phone(value).IsValid())
+}
+
+func validateIdCard(fl validator.FieldLevel) bool {
+	value, ok := stringValue(fl)
+	return ok && (value == "" || IdCard(value).IsValid())
+}
+
+func validateMoney(fl validator.FieldLevel) bool {
+	var value float64
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		value = field.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(field.Uint())
+	case reflect.String:
+		text := strings.TrimSpace(field.String())
+		if text == "" {
+			return true
+		}
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return false
+		}
+		value = v
+	default:
+		return false
+	}
+	if math.Abs(value*100-math.Round(value*100)) > 1e-6 {
+		return false
+	}
+	if param := fl.Param(); param != "" {
+		minText, maxText, _ := strings.Cut(param, "~")
+		if minText != "" {
+			if min, err := strconv.ParseFloat(minText, 64); err != nil || value < min {
+				return false
+			}
+		}
+		if maxText != "" {
+			if max, err := strconv.ParseFloat(maxText, 64); err != nil || value > max {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validatePassword(fl validator.FieldLevel) bool {
+	if fl.Field().Kind() != reflect.String {
+		return false
+	}
+	value := fl.Field().String()
+	if value == "" {
+		return true
+	}
+	level := PasswordWeak
+	if param := fl.Param(); param != "" {
+		if v, err := strconv.Atoi(param); err == nil {
+			level = v
+		}
+	}
+	return passwordLevelError(value, level) == ""
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/lgdzz/vingo-utils-exception/exception"
 	"github.com/lgdzz/vingo-utils-v3/ctype"
+	"github.com/lgdzz/vingo-utils-v3/metrics"
 	"github.com/lgdzz/vingo-utils-v3/pool"
 	"github.com/lgdzz/vingo-utils-v3/vingo"
 	"gorm.io/gorm"
@@ -26,6 +27,7 @@ type Api struct {
 	*gorm.DB
 	*Common
 	Adapter
+	Name      string // 注册表中的连接名称
 	Config    Config
 	ChangeLog func(tx *gorm.DB, option ChangeLogOption)
 }
@@ -51,7 +53,7 @@ func NewDatabase(config Config) *Api {
 	// 公共方法
 	api.Common = NewCommon(api.DB)
 	// 设置密文字段的key
-	ctype.Secret = []byte(config.Secret)
+	setSecret(api, config)
 
 	// 注册统一异常插件
 	RegisterAfterQuery(api)
@@ -59,9 +61,69 @@ func NewDatabase(config Config) *Api {
 	RegisterBeforeUpdate(api)
 	RegisterAfterUpdate(api)
 	RegisterAfterDelete(api)
+	RegisterBlindIndex(api)
+
+	// 停机时关闭连接池
+	vingo.RegisterShutdown(fmt.Sprintf("数据库[%v]", api.Address()), vingo.ShutdownStorage, func(ctx context.Context) error {
+		api.Close()
+		return nil
+	})
+
+	// 连接池指标
+	metrics.RegisterCollector(api.collectMetrics)
 	return api
 }
 
+// collectMetrics 连接池状态，来自 sql.DB.Stats
+func (s *Api) collectMetrics(w *metrics.Writer) {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return
+	}
+	stats := sqlDB.Stats()
+	labels := metrics.Labels{"db": fmt.Sprintf("%v:%v/%v", s.Config.Host, s.Config.Port, s.Config.Dbname)}
+	w.Gauge("db_pool_max_open_connections", "最大连接数", labels, float64(stats.MaxOpenConnections))
+	w.Gauge("db_pool_open_connections", "当前连接数", labels, float64(stats.OpenConnections))
+	w.Gauge("db_pool_in_use_connections", "使用中的连接数", labels, float64(stats.InUse))
+	w.Gauge("db_pool_idle_connections", "空闲连接数", labels, float64(stats.Idle))
+	w.Counter("db_pool_wait_total", "等待连接的累计次数", labels, float64(stats.WaitCount))
+	w.Counter("db_pool_wait_seconds_total", "等待连接的累计时间", labels, stats.WaitDuration.Seconds())
+	w.Counter("db_pool_max_idle_closed_total", "超过最大空闲数关闭的连接数", labels, float64(stats.MaxIdleClosed))
+	w.Counter("db_pool_max_lifetime_closed_total", "超过最大生命周期关闭的连接数", labels, float64(stats.MaxLifetimeClosed))
+}
+
+// setSecret 设置密文字段的key
+// 每个连接使用自己配置的 secret、密钥环和盲索引key（挂载为 ctype.KeySet），未配置的部分使用全局配置；
+// 全局配置未设置时使用首个配置了对应key的连接，供脱离连接的 ctype.Encrypt 等方法使用
+func setSecret(api *Api, config Config) {
+	if config.Secret == "" && config.Keyring == nil && config.BlindKey == "" {
+		return
+	}
+	var active string
+	var keys map[string]string
+	if config.Keyring != nil {
+		active, keys = config.Keyring.Active, config.Keyring.Keys
+	}
+	if err := api.DB.Use(ctype.NewKeySet(config.Secret, active, keys, config.BlindKey)); err != nil {
+		panic(fmt.Sprintf("插件注册失败: %v", err.Error()))
+	}
+
+	if config.Secret != "" && len(ctype.Secret) == 0 {
+		ctype.Secret = []byte(config.Secret)
+	}
+	if config.Keyring != nil && ctype.ActiveKeyId() == "" {
+		for id, key := range keys {
+			ctype.RegisterKey(id, []byte(key))
+		}
+		if active != "" {
+			ctype.SetActiveKey(active)
+		}
+	}
+	if config.BlindKey != "" && len(ctype.BlindKey) == 0 {
+		ctype.BlindKey = []byte(config.BlindKey)
+	}
+}
+
 // RegisterAfterQuery 注册统一查询异常插件
 func RegisterAfterQuery(api *Api) {
 
@@ -240,6 +302,12 @@ type QueryListOption[T any] struct {
 
 	IsTree       bool // 是否返回树结构，只支持id,pid为number类型的主键，其他情况使用ListCallback自定义
 	ListCallback func(list []T) any
+
+	Summary []Measure // 汇总指标，仅分页模式有效
+	Facets  []Facet   // 分组计数，仅分页模式有效
+
+	SortMap  map[string]string // 排序字段映射，仅分页模式有效
+	FieldMap map[string]string // 返回字段白名单，仅分页模式有效
 }
 
 // QueryList 列表查询
@@ -290,6 +358,10 @@ func QueryList[T any](db *gorm.DB, pq PageQuery, option *QueryListOption[T]) any
 		IterateePool: option.IterateePool,
 		PoolResult:   option.PoolResult,
 		MaxWorkers:   option.MaxWorkers,
+		Summary:      option.Summary,
+		Facets:       option.Facets,
+		SortMap:      option.SortMap,
+		FieldMap:     option.FieldMap,
 	})
 }
 
@@ -0,0 +1,346 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：冷数据归档
+//
+// 将业务大表中超过N个月的数据按批次迁移到归档表（同库或其他 db.Api 连接），
+// 每批次：复制 -> 校验（条数或校验和） -> 删除原数据 -> 记录进度。
+// 复制前会先清理目标表中同主键的数据，中断后重新执行不会产生重复数据。
+//
+// 用法：
+//
+//	archiver := archive.New(api, archive.Table{Name: "order", Column: "created_at", Months: 12, Window: "01:00-05:00"})
+//	archiver.Migrate()
+//	archiver.Start(time.Hour) // 或 archiver.Run() 手动执行
+//
+// 注意：源表和目标连接需为同一种数据库，主键需为可排序的单列主键
+// *****************************************************************************
+
+package archive
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/cryptor"
+	"github.com/lgdzz/vingo-utils-v3/db"
+	"github.com/lgdzz/vingo-utils-v3/moment"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	VerifyCount    = "count"    // 按条数校验
+	VerifyChecksum = "checksum" // 按内容校验和校验
+)
+
+type Table struct {
+	Name        string  // 源表名
+	Column      string  // 时间列，如 created_at
+	Months      int     // 保留最近N个月的数据，更早的数据归档
+	PrimaryKey  string  // 主键列，默认 id
+	BatchSize   int     // 每批次数量，默认1000
+	Target      *db.Api // 归档目标连接，默认与源表同库
+	TargetTable string  // 归档表名，默认 {Name}_archive
+	Verify      string  // 校验方式：count|checksum，默认count
+	Window      string  // 允许运行的时间窗口，如 01:00-05:00，支持跨天 22:00-06:00，为空不限制
+}
+
+type Summary struct {
+	Table    string        `json:"table"`
+	Target   string        `json:"target"`
+	Cutoff   string        `json:"cutoff"`
+	Batches  int           `json:"batches"`
+	Archived int64         `json:"archived"`
+	Duration time.Duration `json:"duration"`
+	Stopped  string        `json:"stopped,omitempty"` // 提前结束原因
+	Error    string        `json:"error,omitempty"`
+}
+
+type Archiver struct {
+	api    *db.Api
+	tables []Table
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 新建归档任务
+func New(api *db.Api, tables ...Table) *Archiver {
+	for i := range tables {
+		t := &tables[i]
+		if t.Name == "" || t.Column == "" || t.Months <= 0 {
+			panic("归档配置不完整：Name、Column、Months 必填")
+		}
+		if t.PrimaryKey == "" {
+			t.PrimaryKey = "id"
+		}
+		if t.BatchSize <= 0 {
+			t.BatchSize = 1000
+		}
+		if t.Target == nil {
+			t.Target = api
+		}
+		if t.TargetTable == "" {
+			t.TargetTable = t.Name + "_archive"
+		}
+		if t.Verify == "" {
+			t.Verify = VerifyCount
+		}
+		if t.Window != "" {
+			parseWindow(t.Window)
+		}
+	}
+	return &Archiver{api: api, tables: tables}
+}
+
+// Migrate 创建进度表和归档表
+func (s *Archiver) Migrate() {
+	if !s.api.DB.Migrator().HasTable(&Checkpoint{}) {
+		if err := s.api.DB.Migrator().CreateTable(&Checkpoint{}); err != nil {
+			panic(fmt.Sprintf("创建归档进度表失败: %v", err))
+		}
+	}
+	for _, t := range s.tables {
+		createArchiveTable(s.api, t)
+	}
+}
+
+// Start 定时执行归档，每次执行都会在时间窗口内尽量处理完
+func (s *Archiver) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.RunContext(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止归档，等待当前批次完成
+func (s *Archiver) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Run 执行一次归档
+func (s *Archiver) Run() []Summary {
+	return s.RunContext(context.Background())
+}
+
+// RunContext 执行一次归档，ctx 取消后在当前批次完成时结束
+func (s *Archiver) RunContext(ctx context.Context) []Summary {
+	// 同一实例内不并发执行
+	if !s.mu.TryLock() {
+		return nil
+	}
+	defer s.mu.Unlock()
+
+	var result []Summary
+	for _, t := range s.tables {
+		summary := s.runTable(ctx, t)
+		result = append(result, summary)
+		if summary.Archived > 0 || summary.Error != "" {
+			vingo.LogInfo(fmt.Sprintf("[数据归档]表：%v，目标：%v，截止：%v，批次：%d，归档：%d条，耗时：%v，结束原因：%v，错误：%v",
+				summary.Table, summary.Target, summary.Cutoff, summary.Batches, summary.Archived, summary.Duration, summary.Stopped, summary.Error))
+		}
+	}
+	return result
+}
+
+func (s *Archiver) runTable(ctx context.Context, t Table) (summary Summary) {
+	start := time.Now()
+	cutoff := moment.GetMonthFirstMoment(time.Now().AddDate(0, -t.Months, 0))
+	summary = Summary{
+		Table:  t.Name,
+		Target: t.TargetTable,
+		Cutoff: cutoff.Format(moment.DateTimeFormat),
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			summary.Error = fmt.Sprintf("%v", r)
+		}
+		summary.Duration = time.Since(start)
+	}()
+
+	checkpoint := s.loadCheckpoint(t, summary.Cutoff)
+	for {
+		if ctx.Err() != nil {
+			summary.Stopped = "任务已停止"
+			return
+		}
+		if t.Window != "" && !inWindow(t.Window, time.Now()) {
+			summary.Stopped = "超出运行时间窗口"
+			return
+		}
+
+		archived, lastKey := s.batch(t, cutoff, checkpoint.LastKey)
+		if archived == 0 {
+			summary.Stopped = "已完成"
+			return
+		}
+		summary.Batches++
+		summary.Archived += archived
+
+		checkpoint.LastKey = lastKey
+		checkpoint.Archived += archived
+		checkpoint.UpdatedAt = moment.NowLocalTime()
+		s.api.DB.Save(&checkpoint)
+	}
+}
+
+// batch 归档一个批次，返回归档数量和本批次最大主键
+func (s *Archiver) batch(t Table, cutoff time.Time, lastKey string) (int64, string) {
+	pk := clause.Column{Name: t.PrimaryKey}
+	source := s.api.DB.Table(t.Name).Where("? < ?", clause.Column{Name: t.Column}, cutoff)
+	if lastKey != "" {
+		source = source.Where("? > ?", pk, lastKey)
+	}
+
+	var rows []map[string]any
+	source.Order(clause.OrderByColumn{Column: pk}).Limit(t.BatchSize).Find(&rows)
+	if len(rows) == 0 {
+		return 0, lastKey
+	}
+
+	keys := make([]any, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row[t.PrimaryKey])
+	}
+
+	// 同库时复制和删除在同一事务内完成；跨库时先提交归档数据，再删除原数据，避免数据丢失
+	sameDb := t.Target == s.api
+	t.Target.FastCommit(func(tx *gorm.DB) {
+		// 先清理目标表中同主键数据，保证重复执行幂等
+		tx.Exec("DELETE FROM ? WHERE ? IN ?", clause.Table{Name: t.TargetTable}, pk, keys)
+		tx.Table(t.TargetTable).Create(&rows)
+		verify(tx, t, keys, rows)
+		if sameDb {
+			deleteSource(tx, t, keys)
+		}
+	})
+	if !sameDb {
+		deleteSource(s.api.DB, t, keys)
+	}
+
+	return int64(len(rows)), vingo.ToString(keys[len(keys)-1])
+}
+
+func deleteSource(tx *gorm.DB, t Table, keys []any) {
+	result := tx.Exec("DELETE FROM ? WHERE ? IN ?", clause.Table{Name: t.Name}, clause.Column{Name: t.PrimaryKey}, keys)
+	if result.Error != nil {
+		panic(result.Error.Error())
+	}
+	if result.RowsAffected != int64(len(keys)) {
+		panic(fmt.Sprintf("删除原数据数量不一致，应删除%d条，实际%d条", len(keys), result.RowsAffected))
+	}
+}
+
+// verify 校验归档数据，不一致时panic回滚
+func verify(tx *gorm.DB, t Table, keys []any, rows []map[string]any) {
+	pk := clause.Column{Name: t.PrimaryKey}
+	query := tx.Table(t.TargetTable).Where("? IN ?", pk, keys)
+
+	switch t.Verify {
+	case VerifyChecksum:
+		var copied []map[string]any
+		query.Order(clause.OrderByColumn{Column: pk}).Find(&copied)
+		if checksum(copied) != checksum(rows) {
+			panic(fmt.Sprintf("归档数据校验和不一致，表：%v", t.Name))
+		}
+	default:
+		var count int64
+		query.Count(&count)
+		if count != int64(len(rows)) {
+			panic(fmt.Sprintf("归档数据条数不一致，应为%d条，实际%d条", len(rows), count))
+		}
+	}
+}
+
+// checksum 按行按列名排序后计算md5
+func checksum(rows []map[string]any) string {
+	var builder strings.Builder
+	for _, row := range rows {
+		columns := make([]string, 0, len(row))
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			builder.WriteString(column)
+			builder.WriteString("=")
+			builder.WriteString(normalize(row[column]))
+			builder.WriteString(";")
+		}
+		builder.WriteString("\n")
+	}
+	return cryptor.Md5(builder.String())
+}
+
+func normalize(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// createArchiveTable 根据源表结构创建归档表
+func createArchiveTable(api *db.Api, t Table) {
+	if t.Target.DB.Migrator().HasTable(t.TargetTable) {
+		return
+	}
+	columns, err := api.GetColumns(t.Name)
+	if err != nil {
+		panic(fmt.Sprintf("获取表[%v]字段失败: %v", t.Name, err))
+	}
+	if len(columns) == 0 {
+		panic(fmt.Sprintf("表[%v]不存在或没有字段", t.Name))
+	}
+
+	isMysql := t.Target.Config.Driver != "pgsql"
+	var defs []string
+	for _, col := range columns {
+		def := fmt.Sprintf("  %v %v", quote(t.Target, col.Field), col.Type)
+		if strings.EqualFold(col.Null, "NO") {
+			def += " NOT NULL"
+		} else {
+			def += " NULL"
+		}
+		if isMysql && col.Comment != "" {
+			def += fmt.Sprintf(" COMMENT '%v'", strings.ReplaceAll(col.Comment, "'", "''"))
+		}
+		defs = append(defs, def)
+	}
+	defs = append(defs, fmt.Sprintf("  PRIMARY KEY (%v)", quote(t.Target, t.PrimaryKey)))
+
+	stmt := fmt.Sprintf("CREATE TABLE %v (\n%v\n)", quote(t.Target, t.TargetTable), strings.Join(defs, ",\n"))
+	if err = t.Target.DB.Exec(stmt).Error; err != nil {
+		panic(fmt.Sprintf("创建归档表[%v]失败: %v", t.TargetTable, err))
+	}
+}
+
+func quote(api *db.Api, name string) string {
+	return api.DB.Statement.Quote(name)
+}
@@ -0,0 +1,80 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：归档进度、运行时间窗口
+// *****************************************************************************
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/moment"
+	"gorm.io/gorm"
+)
+
+// CheckpointTableName 归档进度表名
+var CheckpointTableName = "vingo_archive_checkpoint"
+
+type Checkpoint struct {
+	Source    string            `gorm:"primaryKey;column:source;size:100" json:"source"` // 源表名
+	Cutoff    string            `gorm:"column:cutoff;size:20" json:"cutoff"`             // 本轮归档截止时间
+	LastKey   string            `gorm:"column:last_key;size:100" json:"lastKey"`         // 本轮已归档的最大主键
+	Archived  int64             `gorm:"column:archived" json:"archived"`                 // 累计归档数量
+	UpdatedAt *moment.LocalTime `gorm:"column:updated_at" json:"updatedAt"`              // 更新时间
+}
+
+func (s *Checkpoint) TableName() string {
+	return CheckpointTableName
+}
+
+// loadCheckpoint 读取进度，截止时间变化时从头开始扫描
+func (s *Archiver) loadCheckpoint(t Table, cutoff string) Checkpoint {
+	var checkpoint Checkpoint
+	err := s.api.DB.Where("source = ?", t.Name).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		checkpoint = Checkpoint{Source: t.Name}
+	}
+	if checkpoint.Cutoff != cutoff {
+		checkpoint.Cutoff = cutoff
+		checkpoint.LastKey = ""
+	}
+	return checkpoint
+}
+
+// Checkpoints 查询归档进度
+func (s *Archiver) Checkpoints() []Checkpoint {
+	var list []Checkpoint
+	s.api.DB.Order("source asc").Find(&list)
+	return list
+}
+
+// parseWindow 解析时间窗口，返回开始和结束的分钟数
+func parseWindow(window string) (int, int) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		panic(fmt.Sprintf("归档时间窗口格式错误，正确格式：01:00-05:00，实际：%v", window))
+	}
+	return parseClock(parts[0]), parseClock(parts[1])
+}
+
+func parseClock(text string) int {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		panic(fmt.Sprintf("归档时间窗口格式错误，正确格式：01:00-05:00，实际：%v", text))
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// inWindow 当前时间是否在窗口内，开始大于结束时表示跨天
+func inWindow(window string, now time.Time) bool {
+	start, end := parseWindow(window)
+	current := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
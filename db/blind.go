@@ -0,0 +1,133 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：盲索引插件，创建和更新时根据 blind 标签自动计算索引列，标签说明见 ctype/blind.go
+//
+// 更新时：
+// Save/Updates(struct) 来源字段为空时不修改索引（Updates 会忽略零值字段）；
+// Updates(map) 中包含来源字段时按新值重新计算，值为空时清空索引。
+// *****************************************************************************
+
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lgdzz/vingo-utils-v3/ctype"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type blindField struct {
+	index  *schema.Field // 索引字段
+	source *schema.Field // 来源字段
+	mode   string        // 归一化方式
+}
+
+// RegisterBlindIndex 注册盲索引插件
+func RegisterBlindIndex(api *Api) {
+	err := api.DB.Callback().Create().Before("gorm:create").Register("vingo:blind_create", func(tx *gorm.DB) {
+		fields := blindFields(tx)
+		if len(fields) == 0 {
+			return
+		}
+		rv := tx.Statement.ReflectValue
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				fillBlindRow(tx, fields, reflect.Indirect(rv.Index(i)), true)
+			}
+		case reflect.Struct:
+			fillBlindRow(tx, fields, rv, true)
+		}
+	})
+	if err != nil {
+		panic(fmt.Sprintf("插件注册失败: %v", err.Error()))
+	}
+
+	err = api.DB.Callback().Update().Before("gorm:update").Register("vingo:blind_update", func(tx *gorm.DB) {
+		fields := blindFields(tx)
+		if len(fields) == 0 {
+			return
+		}
+		switch dest := tx.Statement.Dest.(type) {
+		case map[string]any:
+			for _, f := range fields {
+				value, ok := dest[f.source.DBName]
+				if !ok {
+					value, ok = dest[f.source.Name]
+				}
+				if ok {
+					dest[f.index.DBName] = ctype.KeySetOf(tx).BlindIndex(blindText(value), f.mode)
+				}
+			}
+		default:
+			// Model(&row).Updates(User{...}) 时新值在 Dest 中
+			rv := reflect.Indirect(reflect.ValueOf(tx.Statement.Dest))
+			if rv.Kind() == reflect.Struct && rv.Type() == tx.Statement.Schema.ModelType {
+				fillBlindRow(tx, fields, rv, false)
+			}
+		}
+	})
+	if err != nil {
+		panic(fmt.Sprintf("插件注册失败: %v", err.Error()))
+	}
+}
+
+func blindFields(tx *gorm.DB) []blindField {
+	if tx.Statement.Schema == nil {
+		return nil
+	}
+	var fields []blindField
+	for _, field := range tx.Statement.Schema.Fields {
+		tag := field.Tag.Get("blind")
+		if tag == "" {
+			continue
+		}
+		name, mode, _ := strings.Cut(tag, ",")
+		source := tx.Statement.Schema.LookUpField(name)
+		if source == nil {
+			panic(fmt.Sprintf("字段[%v]盲索引来源字段不存在：%v", field.Name, name))
+		}
+		fields = append(fields, blindField{index: field, source: source, mode: mode})
+	}
+	return fields
+}
+
+func fillBlindRow(tx *gorm.DB, fields []blindField, row reflect.Value, create bool) {
+	ctx := tx.Statement.Context
+	for _, f := range fields {
+		value, _ := f.source.ValueOf(ctx, row)
+		text := blindText(value)
+		if text == "" && !create {
+			continue
+		}
+		index := ctype.KeySetOf(tx).BlindIndex(text, f.mode)
+		if !create {
+			tx.Statement.SetColumn(f.index.DBName, index, true)
+			continue
+		}
+		if err := f.index.Set(ctx, row, index); err != nil {
+			panic(fmt.Sprintf("字段[%v]填充盲索引失败：%v", f.index.Name, err))
+		}
+	}
+}
+
+func blindText(value any) string {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("%v", rv.Interface())
+}
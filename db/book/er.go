@@ -0,0 +1,276 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：ER图生成
+//
+// 表关系优先读取数据库外键，没有外键的 *_id 字段按命名约定推断关联表：
+// dept_id -> dept / {当前表前缀}dept / depts，pid -> 当前表
+// 输出格式：
+// mermaid  Mermaid erDiagram 文本
+// markdown 包含 mermaid 代码块的 Markdown，可直接放入文档
+// dot      Graphviz DOT，dot -Tsvg er.dot -o er.svg 生成SVG
+// *****************************************************************************
+
+package book
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ErMermaid  = "mermaid"
+	ErMarkdown = "markdown"
+	ErDot      = "dot"
+)
+
+type ErOption struct {
+	Format       string   // 输出格式：mermaid|markdown|dot，默认mermaid
+	Prefix       []string // 只包含指定前缀的表，为空时包含所有表
+	DisableInfer bool     // 不按 *_id 命名推断关系
+	OnlyKeys     bool     // 只输出主键和关联字段，表较多时图更清晰
+}
+
+type Relation struct {
+	Table     string // 子表
+	Column    string // 子表字段
+	RefTable  string // 父表
+	RefColumn string // 父表字段
+	Inferred  bool   // 是否按命名推断
+}
+
+// BuildMysqlEr 生成mysql数据库ER图
+func BuildMysqlEr(db *gorm.DB, option ErOption) string {
+	dbName, tables := loadMysqlTables(db)
+	var relations []Relation
+	err := db.Raw("SELECT TABLE_NAME AS `table`, COLUMN_NAME AS `column`, REFERENCED_TABLE_NAME AS ref_table, REFERENCED_COLUMN_NAME AS ref_column FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = ? AND REFERENCED_TABLE_NAME IS NOT NULL", dbName).Scan(&relations).Error
+	if err != nil {
+		panic(err)
+	}
+	return buildEr(dbName, tables, relations, option)
+}
+
+// BuildPgsqlEr 生成pgsql数据库ER图
+func BuildPgsqlEr(db *gorm.DB, option ErOption) string {
+	dbName, tables := loadPgsqlTables(db)
+	var relations []Relation
+	err := db.Raw(`
+		SELECT
+			c.relname AS "table",
+			a.attname AS "column",
+			rc.relname AS ref_table,
+			ra.attname AS ref_column
+		FROM pg_constraint ct
+		JOIN pg_class c ON c.oid = ct.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class rc ON rc.oid = ct.confrelid
+		JOIN pg_attribute a ON a.attrelid = ct.conrelid AND a.attnum = ct.conkey[1]
+		JOIN pg_attribute ra ON ra.attrelid = ct.confrelid AND ra.attnum = ct.confkey[1]
+		WHERE ct.contype = 'f' AND n.nspname = 'public'
+	`).Scan(&relations).Error
+	if err != nil {
+		panic(err)
+	}
+	return buildEr(dbName, tables, relations, option)
+}
+
+func buildEr(dbName string, tables []TableItem, relations []Relation, option ErOption) string {
+	tables = filterTables(tables, option.Prefix)
+	names := map[string]bool{}
+	for _, t := range tables {
+		names[t.Name] = true
+	}
+
+	// 只保留两端都在范围内的外键
+	var result []Relation
+	exists := map[string]bool{}
+	for _, r := range relations {
+		if names[r.Table] && names[r.RefTable] {
+			result = append(result, r)
+			exists[r.Table+"."+r.Column] = true
+		}
+	}
+	if !option.DisableInfer {
+		for _, t := range tables {
+			for _, col := range t.Columns {
+				if exists[t.Name+"."+col.Field] || col.Key == "PRI" {
+					continue
+				}
+				if ref := inferTable(t.Name, col.Field, names); ref != "" {
+					result = append(result, Relation{Table: t.Name, Column: col.Field, RefTable: ref, RefColumn: "id", Inferred: true})
+				}
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].Column < result[j].Column
+	})
+
+	switch option.Format {
+	case ErDot:
+		return buildDot(dbName, tables, result, option)
+	case ErMarkdown:
+		return fmt.Sprintf("## %v ER图\n\n> 生成时间：%v，虚线为按字段命名推断的关系\n\n```mermaid\n%v```\n", dbName, time.Now().Format("2006-01-02 15:04:05"), buildMermaid(tables, result, option))
+	default:
+		return buildMermaid(tables, result, option)
+	}
+}
+
+func filterTables(tables []TableItem, prefix []string) []TableItem {
+	var result []TableItem
+	for _, t := range tables {
+		if len(prefix) == 0 {
+			result = append(result, t)
+			continue
+		}
+		for _, p := range prefix {
+			if strings.HasPrefix(t.Name, p) {
+				result = append(result, t)
+				break
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// inferTable 按字段命名推断关联表
+func inferTable(table string, column string, names map[string]bool) string {
+	if column == "pid" || column == "parent_id" {
+		return table
+	}
+	base, ok := strings.CutSuffix(column, "_id")
+	if !ok || base == "" {
+		return ""
+	}
+	var candidates = []string{base}
+	// 当前表前缀，如 sys_user.dept_id -> sys_dept
+	if i := strings.Index(table, "_"); i > 0 {
+		candidates = append(candidates, table[:i+1]+base)
+	}
+	candidates = append(candidates, base+"s")
+	for _, name := range candidates {
+		if names[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+func isKeyColumn(table string, col Column, relations []Relation) bool {
+	if col.Key == "PRI" {
+		return true
+	}
+	for _, r := range relations {
+		if r.Table == table && r.Column == col.Field {
+			return true
+		}
+	}
+	return false
+}
+
+func isForeign(table string, column string, relations []Relation) bool {
+	for _, r := range relations {
+		if r.Table == table && r.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// mermaidType mermaid 字段类型不能包含空格和括号
+func mermaidType(t string) string {
+	t, _, _ = strings.Cut(t, "(")
+	return strings.ReplaceAll(strings.TrimSpace(t), " ", "_")
+}
+
+func escape(text string) string {
+	text = strings.ReplaceAll(text, "\"", "'")
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+func buildMermaid(tables []TableItem, relations []Relation, option ErOption) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, t := range tables {
+		b.WriteString(fmt.Sprintf("    %v {\n", t.Name))
+		for _, col := range t.Columns {
+			if option.OnlyKeys && !isKeyColumn(t.Name, col, relations) {
+				continue
+			}
+			var keys []string
+			if col.Key == "PRI" {
+				keys = append(keys, "PK")
+			}
+			if isForeign(t.Name, col.Field, relations) {
+				keys = append(keys, "FK")
+			}
+			b.WriteString(fmt.Sprintf("        %v %v", mermaidType(col.Type), col.Field))
+			if len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ","))
+			}
+			if col.Comment != "" {
+				b.WriteString(fmt.Sprintf(" \"%v\"", escape(col.Comment)))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, r := range relations {
+		line := "--"
+		if r.Inferred {
+			line = ".."
+		}
+		b.WriteString(fmt.Sprintf("    %v ||%vo{ %v : \"%v\"\n", r.RefTable, line, r.Table, r.Column))
+	}
+	return b.String()
+}
+
+func buildDot(dbName string, tables []TableItem, relations []Relation, option ErOption) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("digraph \"%v\" {\n", escape(dbName)))
+	b.WriteString("    graph [rankdir=LR, fontname=\"Microsoft YaHei\"];\n")
+	b.WriteString("    node [shape=plaintext, fontname=\"Microsoft YaHei\", fontsize=10];\n")
+	b.WriteString("    edge [arrowhead=crow, arrowtail=tee, dir=both, fontsize=9];\n")
+	for _, t := range tables {
+		b.WriteString(fmt.Sprintf("    \"%v\" [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n", t.Name))
+		title := t.Name
+		if t.Comment != "" {
+			title += " " + t.Comment
+		}
+		b.WriteString(fmt.Sprintf("        <tr><td colspan=\"2\" bgcolor=\"#dbe8f5\"><b>%v</b></td></tr>\n", htmlEscape(title)))
+		for _, col := range t.Columns {
+			if option.OnlyKeys && !isKeyColumn(t.Name, col, relations) {
+				continue
+			}
+			name := htmlEscape(col.Field)
+			if col.Key == "PRI" {
+				name = "<u>" + name + "</u>"
+			}
+			b.WriteString(fmt.Sprintf("        <tr><td align=\"left\" port=\"%v\">%v</td><td align=\"left\">%v</td></tr>\n", col.Field, name, htmlEscape(col.Type)))
+		}
+		b.WriteString("    </table>>];\n")
+	}
+	for _, r := range relations {
+		style := ""
+		if r.Inferred {
+			style = ", style=dashed"
+		}
+		b.WriteString(fmt.Sprintf("    \"%v\":\"%v\" -> \"%v\":\"%v\" [label=\"%v\"%v];\n", r.RefTable, r.RefColumn, r.Table, r.Column, r.Column, style))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func htmlEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;").Replace(text)
+}
@@ -14,6 +14,32 @@ import (
 )
 
 func BuildMysqlBook(db *gorm.DB) string {
+	dbName, tables := loadMysqlTables(db)
+
+	// 构造 Database 对象
+	database := Database{
+		Name:        dbName,
+		Tables:      tables,
+		ReleaseTime: time.Now().Format("2006年01月02日"),
+	}
+
+	// 渲染模板到 bytes.Buffer
+	var buf bytes.Buffer
+	t, err := template.New("tpl").Parse(BookTpl)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := t.Execute(&buf, database); err != nil {
+		panic(err)
+	}
+
+	// 返回渲染结果的字符串
+	return buf.String()
+}
+
+// loadMysqlTables 读取所有表及字段
+func loadMysqlTables(db *gorm.DB) (string, []TableItem) {
 	var tables []TableItem
 	var dbName string
 	err := db.Raw("SELECT DATABASE()").Row().Scan(&dbName)
@@ -60,25 +86,5 @@ func BuildMysqlBook(db *gorm.DB) string {
 			Columns: sortedColumns,
 		})
 	}
-
-	// 构造 Database 对象
-	database := Database{
-		Name:        dbName,
-		Tables:      tables,
-		ReleaseTime: time.Now().Format("2006年01月02日"),
-	}
-
-	// 渲染模板到 bytes.Buffer
-	var buf bytes.Buffer
-	t, err := template.New("tpl").Parse(BookTpl)
-	if err != nil {
-		panic(err)
-	}
-
-	if err := t.Execute(&buf, database); err != nil {
-		panic(err)
-	}
-
-	// 返回渲染结果的字符串
-	return buf.String()
+	return dbName, tables
 }
@@ -16,6 +16,31 @@ import (
 )
 
 func BuildPgsqlBook(db *gorm.DB) string {
+	dbName, tables := loadPgsqlTables(db)
+
+	database := Database{
+		Name:        dbName,
+		Tables:      tables,
+		ReleaseTime: time.Now().Format("2006年01月02日"),
+	}
+
+	// 渲染模板到 bytes.Buffer
+	var buf bytes.Buffer
+	t, err := template.New("tpl").Parse(BookTpl)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := t.Execute(&buf, database); err != nil {
+		panic(err)
+	}
+
+	// 返回渲染结果的字符串
+	return buf.String()
+}
+
+// loadPgsqlTables 读取所有表及字段
+func loadPgsqlTables(db *gorm.DB) (string, []TableItem) {
 	var tables []TableItem
 	var dbName string
 
@@ -76,24 +101,5 @@ func BuildPgsqlBook(db *gorm.DB) string {
 	}
 
 	_ = slice.SortByField(tables, "Name", "asc")
-
-	database := Database{
-		Name:        dbName,
-		Tables:      tables,
-		ReleaseTime: time.Now().Format("2006年01月02日"),
-	}
-
-	// 渲染模板到 bytes.Buffer
-	var buf bytes.Buffer
-	t, err := template.New("tpl").Parse(BookTpl)
-	if err != nil {
-		panic(err)
-	}
-
-	if err := t.Execute(&buf, database); err != nil {
-		panic(err)
-	}
-
-	// 返回渲染结果的字符串
-	return buf.String()
+	return dbName, tables
 }
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/duke-git/lancet/v2/slice"
+	"github.com/lgdzz/vingo-utils-v3/ctype"
 	"github.com/lgdzz/vingo-utils-v3/moment"
 	"gorm.io/gorm"
 )
@@ -251,6 +252,21 @@ func (s *Common) QueryWhere(db *gorm.DB, input any, column ...string) *gorm.DB {
 	return db
 }
 
+// QueryWhereBlind 盲索引查询，input为明文，column为索引列，mode为索引列的归一化方式；input为空时不加条件，无法生成索引时不匹配任何数据
+// 例：QueryWhereBlind(tx, "13800001234", "phone_bidx")、QueryWhereBlind(tx, "1234", "phone_last4", "last4")
+func (s *Common) QueryWhereBlind(db *gorm.DB, input string, column string, mode ...string) *gorm.DB {
+	db = s.QueryDb(db)
+	if input == "" {
+		return db
+	}
+	index := ctype.KeySetOf(db).BlindIndex(input, mode...)
+	if index == "" {
+		// 输入无法归一化（如 last4 模式下不足4位）时不能放开条件，否则会返回全部数据
+		return db.Where("1 = 0")
+	}
+	return db.Where(fmt.Sprintf("%v = ?", column), index)
+}
+
 // QueryWhereIn 包含查询
 func (s *Common) QueryWhereIn(db *gorm.DB, input TextSlice, column string, columnType ...string) *gorm.DB {
 	db = s.QueryDb(db)
@@ -12,19 +12,33 @@ const (
 )
 
 type Config struct {
-	Host           string `yaml:"host" json:"host"`
-	Port           string `yaml:"port" json:"port"`
-	Dbname         string `yaml:"dbname" json:"dbname"`
-	Schema         string `yaml:"schema" json:"schema"`
-	Username       string `yaml:"username" json:"username"`
-	Password       string `yaml:"password" json:"password"`
-	Charset        string `yaml:"charset" json:"charset"`
-	ConnectTimeout int    `yaml:"connectTimeout" json:"connectTimeout"`
-	MaxIdleConns   int    `yaml:"maxIdleConns" json:"maxIdleConns"`
-	MaxOpenConns   int    `yaml:"maxOpenConns" json:"maxOpenConns"`
-	Driver         string `yaml:"driver" json:"driver"`
-	Secret         string `yaml:"secret" json:"secret"` // ciphertext类型字段key
-	Debug          bool   `yaml:"debug" json:"debug"`
+	Host           string         `yaml:"host" json:"host"`
+	Port           string         `yaml:"port" json:"port"`
+	Dbname         string         `yaml:"dbname" json:"dbname"`
+	Schema         string         `yaml:"schema" json:"schema"`
+	Username       string         `yaml:"username" json:"username"`
+	Password       string         `yaml:"password" json:"password"`
+	Charset        string         `yaml:"charset" json:"charset"`
+	ConnectTimeout int            `yaml:"connectTimeout" json:"connectTimeout"`
+	MaxIdleConns   int            `yaml:"maxIdleConns" json:"maxIdleConns"`
+	MaxOpenConns   int            `yaml:"maxOpenConns" json:"maxOpenConns"`
+	Driver         string         `yaml:"driver" json:"driver"`
+	Secret         string         `yaml:"secret" json:"secret"`     // ciphertext类型字段key，仅用于本连接；配置keyring后仅用于解密历史密文
+	Keyring        *KeyringConfig `yaml:"keyring" json:"keyring"`   // ciphertext类型字段多版本密钥
+	BlindKey       string         `yaml:"blindKey" json:"blindKey"` // 盲索引key，为空时使用secret，均未配置时使用全局配置
+	Debug          bool           `yaml:"debug" json:"debug"`
+}
+
+// KeyringConfig 密钥环，Active 为当前加密使用的密钥ID，Keys 中其余密钥仅用于解密
+//
+//	keyring:
+//	  active: k2
+//	  keys:
+//	    k1: 0123456789abcdef
+//	    k2: fedcba9876543210
+type KeyringConfig struct {
+	Active string            `yaml:"active" json:"active"`
+	Keys   map[string]string `yaml:"keys" json:"keys"`
 }
 
 func (s *Config) StringValue(value *string, defaultValue string) {
@@ -80,6 +80,12 @@ func ParseStructFieldComments(filePath string, structName string) (map[string]st
 ///////////////////////////////////////////////////////////
 
 func GenerateCreateTableSQLList(model interface{}, filePath string, dbType DBType, tableName string, tableComment string) ([]string, error) {
+	return generateCreateTableSQLList(model, filePath, dbType, tableName, tableComment, true)
+}
+
+// generateCreateTableSQLList drop=false 时不生成 DROP 语句，用于只新建不覆盖的场景（如分表）
+// filePath 为空时不解析字段注释
+func generateCreateTableSQLList(model interface{}, filePath string, dbType DBType, tableName string, tableComment string, drop bool) ([]string, error) {
 
 	t := reflect.TypeOf(model)
 
@@ -91,9 +97,13 @@ func GenerateCreateTableSQLList(model interface{}, filePath string, dbType DBTyp
 		return nil, fmt.Errorf("model must be struct")
 	}
 
-	fieldComments, err := ParseStructFieldComments(filePath, t.Name())
-	if err != nil {
-		return nil, err
+	fieldComments := map[string]string{}
+	if filePath != "" {
+		comments, err := ParseStructFieldComments(filePath, t.Name())
+		if err != nil {
+			return nil, err
+		}
+		fieldComments = comments
 	}
 
 	var columns []string
@@ -106,7 +116,7 @@ func GenerateCreateTableSQLList(model interface{}, filePath string, dbType DBTyp
 	// DROP
 	///////////////////////////////////////////////////////////
 
-	if dbType == PGSQL {
+	if dbType == PGSQL && drop {
 
 		sqlList = append(sqlList, fmt.Sprintf(`
 DO $$
@@ -125,11 +135,13 @@ BEGIN
    END IF;
 END
 $$;`, seqName, seqName))
+	}
 
-		sqlList = append(sqlList, fmt.Sprintf("CREATE SEQUENCE %s;", seqName))
+	if dbType == PGSQL {
+		sqlList = append(sqlList, fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s;", seqName))
 	}
 
-	if dbType == MySQL {
+	if dbType == MySQL && drop {
 		sqlList = append(sqlList, fmt.Sprintf("DROP TABLE IF EXISTS `%s`;", tableName))
 	}
 
@@ -295,6 +307,7 @@ $$;`, seqName, seqName))
 	}
 
 	indexes := map[string]*IndexInfo{}
+	var ginIndexes []string
 
 	for i := 0; i < t.NumField(); i++ {
 
@@ -316,6 +329,12 @@ $$;`, seqName, seqName))
 
 			p = strings.TrimSpace(p)
 
+			if p == "gin" {
+				if stmt := GenerateGinIndexSQL(dbType, tableName, columnName, field.Type); stmt != "" {
+					ginIndexes = append(ginIndexes, stmt)
+				}
+			}
+
 			if p == "index" {
 
 				idxName := fmt.Sprintf("%s_%s_idx", tableName, columnName)
@@ -412,9 +431,58 @@ $$;`, seqName, seqName))
 		}
 	}
 
+	sqlList = append(sqlList, ginIndexes...)
+
 	return sqlList, nil
 }
 
+// GenerateGinIndexSQL 生成JSON、数组字段索引，字段标签 gorm:"gin" 时自动生成
+// pgsql：ctype.Jsons 为 GIN 索引，ctype.Strings 为 string_to_array 表达式 GIN 索引
+// mysql：ctype.Jsons 元素为字符串或整数时为多值索引（8.0.17+），其他情况不支持返回空
+func GenerateGinIndexSQL(dbType DBType, tableName string, columnName string, fieldType reflect.Type) string {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	isStrings := strings.HasPrefix(fieldType.String(), "ctype.Strings[")
+	idxName := fmt.Sprintf("%s_%s_gin", tableName, columnName)
+
+	if dbType == PGSQL {
+		column := wrapName(columnName, dbType)
+		if isStrings {
+			column = fmt.Sprintf("string_to_array(%s, ',')", column)
+		}
+		return fmt.Sprintf(
+			"CREATE INDEX %s ON %s USING GIN (%s);",
+			idxName,
+			wrapName(tableName, dbType),
+			column,
+		)
+	}
+
+	if isStrings || fieldType.Kind() != reflect.Slice {
+		return ""
+	}
+
+	var cast string
+	switch fieldType.Elem().Kind() {
+	case reflect.String:
+		cast = "CHAR(255) ARRAY"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cast = "SIGNED ARRAY"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		cast = "UNSIGNED ARRAY"
+	default:
+		return ""
+	}
+	return fmt.Sprintf(
+		"CREATE INDEX `%s` ON `%s` ((CAST(`%s`->'$' AS %s)));",
+		idxName,
+		tableName,
+		columnName,
+		cast,
+	)
+}
+
 ///////////////////////////////////////////////////////////
 // 类型映射 / 工具函数（保持你的原样）
 ///////////////////////////////////////////////////////////
@@ -432,6 +500,16 @@ func mapGoType(t reflect.Type, tag reflect.StructTag, dbType DBType) string {
 
 	typeName := t.String()
 
+	// 早期版本为 VARCHAR(255)，已有表需手动改为 JSON/JSONB，否则与新建的分表列类型不一致
+	if strings.HasPrefix(typeName, "ctype.Jsons[") {
+
+		if dbType == MySQL {
+			return "JSON"
+		}
+
+		return "JSONB"
+	}
+
 	switch typeName {
 
 	case "moment.LocalTime":
@@ -582,3 +660,37 @@ func CreateTable(tx *gorm.DB, model interface{}, tableName string, tableComment
 		}
 	}
 }
+
+// CreateTableIfNotExists 表不存在时创建，已存在（包括其他实例同时创建）则跳过，不会删除已有数据，返回是否由本次创建
+// filePath 为空时不生成字段注释
+func CreateTableIfNotExists(tx *gorm.DB, model interface{}, tableName string, tableComment string, filePath string, dbType string) bool {
+
+	if tx.Migrator().HasTable(tableName) {
+		return false
+	}
+
+	sqlList, err := generateCreateTableSQLList(
+		model,
+		filePath,
+		DBType(dbType),
+		tableName,
+		tableComment,
+		false,
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, stmt := range sqlList {
+		if err := tx.Exec(stmt).Error; err != nil {
+			// 多个实例同时建表时，后执行的 CREATE TABLE 会因表已存在失败，
+			// 此时表和后续的主键、索引由先执行的实例创建，视为成功
+			if strings.HasPrefix(strings.TrimSpace(stmt), "CREATE TABLE") && tx.Migrator().HasTable(tableName) {
+				return false
+			}
+			panic(err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,395 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：YAML数据填充，用于测试和演示环境初始化数据
+//
+// 文件格式：顶层为表名，其下为 行标识: 字段 的映射，字段名支持结构体字段名、列名或json名
+//
+//	dept:
+//	  root:
+//	    name: 总部
+//	  sales:
+//	    pid: $dept.root.id      # 引用其他行写入后的字段值，格式 $表名.行标识.字段
+//	    name: 销售部
+//	account:
+//	  admin:
+//	    deptId: $dept.sales.id
+//	    password: "Abc@123456"   # ctype.Password 字段填写明文密码，自动生成密码对象
+//	    phone: "13800001234"     # ctype.Ciphertext 字段填写明文，写入时自动加密
+//	    remark: $$不是引用        # $$ 开头表示普通文本 $不是引用
+//
+// 用法：
+//
+//	fixture.Register[model.Dept](pathutil.Option{}) // 树形结构传入路径配置，写入后自动计算 path、len
+//	fixture.Register[model.Account]()
+//	fixture.Seed(api, "fixtures/demo")  // 目录或文件，多个文件按文件名顺序加载
+//	fixture.Reset(api, "fixtures/demo") // 按依赖倒序清空涉及的表后重新写入
+// *****************************************************************************
+
+package fixture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lgdzz/vingo-utils-v3/ctype"
+	"github.com/lgdzz/vingo-utils-v3/db"
+	"github.com/lgdzz/vingo-utils-v3/db/pathutil"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type model struct {
+	new  func() any
+	tree func(tx *gorm.DB, row any)
+}
+
+var (
+	mu     sync.RWMutex
+	models = map[reflect.Type]*model{}
+)
+
+// Register 注册填充模型，传入路径配置时按树形结构在写入后计算路径
+func Register[T any](tree ...pathutil.Option) {
+	m := &model{new: func() any { return new(T) }}
+	if len(tree) > 0 {
+		option := tree[0]
+		m.tree = func(tx *gorm.DB, row any) {
+			current := option
+			current.Tx = tx
+			pathutil.SetPathWithCreate[T](row.(*T), nil, &current)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	models[reflect.TypeOf((*T)(nil)).Elem()] = m
+}
+
+// row 文件中的一行数据
+type row struct {
+	table  string
+	label  string
+	values map[string]any
+	deps   []string // 依赖的行，格式 表名.行标识
+	value  any      // 写入后的模型
+}
+
+func (s *row) key() string {
+	return s.table + "." + s.label
+}
+
+type loader struct {
+	api    *db.Api
+	tables map[string]*table
+	rows   []*row
+	index  map[string]*row
+}
+
+type table struct {
+	model  *model
+	schema *schema.Schema
+}
+
+// Seed 写入数据，paths 为文件或目录
+func Seed(api *db.Api, paths ...string) {
+	l := newLoader(api, paths)
+	rows := l.sort()
+	api.FastCommit(func(tx *gorm.DB) {
+		for _, r := range rows {
+			l.insert(tx, r)
+		}
+	})
+	fmt.Println(fmt.Sprintf("数据填充完成，共%d行", len(rows)))
+}
+
+// Reset 按依赖倒序清空文件中涉及的表，然后重新写入
+func Reset(api *db.Api, paths ...string) {
+	l := newLoader(api, paths)
+	rows := l.sort()
+
+	var order []string
+	seen := map[string]bool{}
+	for _, r := range rows {
+		if !seen[r.table] {
+			seen[r.table] = true
+			order = append(order, r.table)
+		}
+	}
+	names := make([]string, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		names = append(names, l.tables[order[i]].schema.Table)
+	}
+	truncate(api, names)
+
+	api.FastCommit(func(tx *gorm.DB) {
+		for _, r := range rows {
+			l.insert(tx, r)
+		}
+	})
+	fmt.Println(fmt.Sprintf("数据重置完成，清空%d个表，写入%d行", len(order), len(rows)))
+}
+
+// truncate 清空表，存在外键时 pgsql 一次清空全部表，mysql 在同一连接中临时关闭外键检查
+func truncate(api *db.Api, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, api.DB.Statement.Quote(name))
+	}
+	var err error
+	if api.Config.Driver == "pgsql" {
+		err = api.DB.Exec(fmt.Sprintf("TRUNCATE TABLE %v RESTART IDENTITY", strings.Join(quoted, ", "))).Error
+	} else {
+		err = api.DB.Connection(func(conn *gorm.DB) (err error) {
+			if err = conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return
+			}
+			defer func() {
+				if restoreErr := conn.Exec("SET FOREIGN_KEY_CHECKS = 1").Error; err == nil {
+					err = restoreErr
+				}
+			}()
+			for i, name := range quoted {
+				if err = conn.Exec("TRUNCATE TABLE " + name).Error; err != nil {
+					return fmt.Errorf("%v: %w", names[i], err)
+				}
+			}
+			return
+		})
+	}
+	if err != nil {
+		panic(fmt.Sprintf("清空表[%v]失败: %v", strings.Join(names, ","), err))
+	}
+}
+
+func newLoader(api *db.Api, paths []string) *loader {
+	l := &loader{api: api, tables: map[string]*table{}, index: map[string]*row{}}
+	for _, file := range files(paths) {
+		l.parse(file)
+	}
+	return l
+}
+
+// files 展开目录，目录内按文件名排序
+func files(paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			panic(fmt.Sprintf("填充文件不存在：%v", p))
+		}
+		if !info.IsDir() {
+			result = append(result, p)
+			continue
+		}
+		var list []string
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, _ := filepath.Glob(filepath.Join(p, pattern))
+			list = append(list, matches...)
+		}
+		sort.Strings(list)
+		result = append(result, list...)
+	}
+	return result
+}
+
+func (s *loader) parse(file string) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		panic(fmt.Sprintf("读取填充文件失败：%v", err))
+	}
+	var doc yaml.Node
+	if err = yaml.Unmarshal(content, &doc); err != nil {
+		panic(fmt.Sprintf("填充文件[%v]格式错误：%v", file, err))
+	}
+	if len(doc.Content) == 0 {
+		return
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		panic(fmt.Sprintf("填充文件[%v]顶层需为 表名: 数据 格式", file))
+	}
+	// yaml.Node 保留文件中的顺序，无依赖关系时按文件顺序写入
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name := root.Content[i].Value
+		s.table(name)
+		rows := root.Content[i+1]
+		if rows.Kind != yaml.MappingNode {
+			panic(fmt.Sprintf("填充文件[%v]表[%v]需为 行标识: 字段 格式", file, name))
+		}
+		for j := 0; j+1 < len(rows.Content); j += 2 {
+			r := &row{table: name, label: rows.Content[j].Value}
+			if err = rows.Content[j+1].Decode(&r.values); err != nil {
+				panic(fmt.Sprintf("填充文件[%v]行[%v]格式错误：%v", file, r.key(), err))
+			}
+			if _, ok := s.index[r.key()]; ok {
+				panic(fmt.Sprintf("填充数据行标识重复：%v", r.key()))
+			}
+			s.index[r.key()] = r
+			s.rows = append(s.rows, r)
+		}
+	}
+}
+
+// table 根据表名查找注册的模型
+func (s *loader) table(name string) *table {
+	if t, ok := s.tables[name]; ok {
+		return t
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, m := range models {
+		parsed, err := schema.Parse(m.new(), &sync.Map{}, s.api.DB.NamingStrategy)
+		if err != nil {
+			panic(fmt.Sprintf("解析填充模型失败：%v", err))
+		}
+		if parsed.Table == name {
+			s.tables[name] = &table{model: m, schema: parsed}
+			return s.tables[name]
+		}
+	}
+	panic(fmt.Sprintf("表[%v]未注册填充模型，请先执行 fixture.Register", name))
+}
+
+// sort 按引用关系排序，被引用的行先写入
+func (s *loader) sort() []*row {
+	for _, r := range s.rows {
+		r.deps = nil
+		for _, value := range r.values {
+			if ref, ok := reference(value); ok {
+				target, _ := splitReference(ref)
+				if _, exists := s.index[target]; !exists {
+					panic(fmt.Sprintf("行[%v]引用的数据不存在：$%v", r.key(), ref))
+				}
+				r.deps = append(r.deps, target)
+			}
+		}
+	}
+
+	var result []*row
+	state := map[string]int{} // 1-访问中，2-已完成
+	var visit func(r *row, path []string)
+	visit = func(r *row, path []string) {
+		switch state[r.key()] {
+		case 1:
+			panic(fmt.Sprintf("填充数据存在循环引用：%v", strings.Join(append(path, r.key()), " -> ")))
+		case 2:
+			return
+		}
+		state[r.key()] = 1
+		for _, dep := range r.deps {
+			visit(s.index[dep], append(path, r.key()))
+		}
+		state[r.key()] = 2
+		result = append(result, r)
+	}
+	for _, r := range s.rows {
+		visit(r, nil)
+	}
+	return result
+}
+
+// reference 判断是否为引用，$$ 开头为转义的普通文本
+func reference(value any) (string, bool) {
+	text, ok := value.(string)
+	if !ok || !strings.HasPrefix(text, "$") || strings.HasPrefix(text, "$$") {
+		return "", false
+	}
+	return text[1:], true
+}
+
+// splitReference 拆分引用为 行(表名.行标识) 和 字段
+func splitReference(ref string) (string, string) {
+	i := strings.LastIndex(ref, ".")
+	if i <= 0 || strings.Count(ref, ".") < 2 {
+		panic(fmt.Sprintf("引用格式错误，正确格式：$表名.行标识.字段，实际：$%v", ref))
+	}
+	return ref[:i], ref[i+1:]
+}
+
+func (s *loader) insert(tx *gorm.DB, r *row) {
+	t := s.tables[r.table]
+	value := t.model.new()
+	rv := reflect.ValueOf(value).Elem()
+	ctx := tx.Statement.Context
+
+	for name, v := range r.values {
+		field := lookupField(t.schema, name)
+		if field == nil {
+			panic(fmt.Sprintf("行[%v]字段不存在：%v", r.key(), name))
+		}
+		v = s.resolve(v)
+		if err := setField(ctx, field, rv, v); err != nil {
+			panic(fmt.Sprintf("行[%v]字段[%v]赋值失败：%v", r.key(), name, err))
+		}
+	}
+
+	tx.Table(t.schema.Table).Create(value)
+	if t.model.tree != nil {
+		t.model.tree(tx, value)
+	}
+	r.value = value
+}
+
+// resolve 解析引用为被引用行的字段值
+func (s *loader) resolve(value any) any {
+	if text, ok := value.(string); ok && strings.HasPrefix(text, "$$") {
+		return text[1:]
+	}
+	ref, ok := reference(value)
+	if !ok {
+		return value
+	}
+	key, name := splitReference(ref)
+	target := s.index[key]
+	field := lookupField(s.tables[target.table].schema, name)
+	if field == nil {
+		panic(fmt.Sprintf("引用的字段不存在：$%v", ref))
+	}
+	result, _ := field.ValueOf(s.api.DB.Statement.Context, reflect.ValueOf(target.value).Elem())
+	return result
+}
+
+// lookupField 按结构体字段名、列名、json名查找字段
+func lookupField(sch *schema.Schema, name string) *schema.Field {
+	if field := sch.LookUpField(name); field != nil {
+		return field
+	}
+	for _, field := range sch.Fields {
+		if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName == name {
+			return field
+		}
+	}
+	return nil
+}
+
+var passwordType = reflect.TypeOf(ctype.Password(""))
+
+func setField(ctx context.Context, field *schema.Field, rv reflect.Value, value any) error {
+	fieldType := field.FieldType
+	if value != nil {
+		text, isText := value.(string)
+		switch {
+		case fieldType == passwordType && isText:
+			// 明文密码生成密码对象，使用简单密码级别，便于演示数据
+			value = ctype.NewPassword(text, ctype.PasswordWeak, false)
+		case isText && fieldType.Kind() == reflect.String:
+			// Ciphertext 等字符串类型直接转换，避免走 Scan 被当作密文解密
+			value = reflect.ValueOf(text).Convert(fieldType).Interface()
+		case isText && fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.String:
+			ptr := reflect.New(fieldType.Elem())
+			ptr.Elem().Set(reflect.ValueOf(text).Convert(fieldType.Elem()))
+			value = ptr.Interface()
+		}
+	}
+	return field.Set(ctx, rv, value)
+}
@@ -7,6 +7,7 @@
 package db
 
 import (
+	"github.com/lgdzz/vingo-utils-v3/db/book"
 	"gorm.io/gorm"
 )
 
@@ -16,11 +17,20 @@ type Adapter interface {
 	GetColumns(tableName string) ([]Column, error)
 
 	Book() string                                  // 数据库字典
+	Er(option book.ErOption) string                // ER图，mermaid/markdown/dot
 	ModelFiles(tableNames ...string) (bool, error) // 模型文件
 
 	QueryWhereFindInSet(db *gorm.DB, query TextSlice, column string) *gorm.DB
 
+	QueryWhereJsonContains(db *gorm.DB, column string, value any) *gorm.DB                           // JSON包含
+	QueryWhereJsonOverlaps(db *gorm.DB, column string, values ...any) *gorm.DB                       // JSON数组包含任一元素
+	QueryWhereJsonHasKey(db *gorm.DB, column string, key string) *gorm.DB                            // JSON存在键
+	QueryWhereJsonHasAnyKey(db *gorm.DB, column string, keys ...string) *gorm.DB                     // JSON存在任一键
+	QueryWhereJsonPath(db *gorm.DB, column string, path string, operator string, value any) *gorm.DB // JSON路径比较
+	QueryWhereArrayOverlaps(db *gorm.DB, column string, values ...any) *gorm.DB                      // 逗号分隔文本包含任一元素
+
 	JsonExtract(column string, key string) string // 提取json字段做为字段
+	Quote(name string) string                     // 标识符加引号，mysql为`name`，pgsql为"name"
 
 	CountWithCondition(condition string) string
 	SumWithCondition(condition string, column string) string
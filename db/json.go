@@ -0,0 +1,68 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：JSON、数组字段查询
+//
+// ctype.Jsons 字段使用 JSON 查询，ctype.Strings（逗号分隔文本）字段使用 QueryWhereArrayOverlaps：
+//
+//	api.QueryWhereJsonContains(tx, "tags", []string{"vip"})      // 包含全部
+//	api.QueryWhereJsonOverlaps(tx, "tags", "vip", "new")         // 包含任一
+//	api.QueryWhereJsonHasKey(tx, "extra", "invoice")             // 存在键
+//	api.QueryWhereJsonPath(tx, "extra", "address.city", "=", "郑州") // 路径比较
+//	api.QueryWhereArrayOverlaps(tx, "role_ids", 1, 2)
+//
+// pgsql 需为 jsonb 类型，配合 GIN 索引使用（ddl.GenerateGinIndexSQL 或字段标签 gorm:"gin"）
+// mysql 需 8.0.17 及以上版本
+// *****************************************************************************
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var jsonPathPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\[[0-9]+\])*(\.[A-Za-z0-9_]+(\[[0-9]+\])*)*$`)
+
+// jsonPathOperators 比较运算符，值为 pgsql jsonpath 中的写法
+var jsonPathOperators = map[string]string{
+	"=":  "==",
+	"!=": "!=",
+	"<>": "!=",
+	">":  ">",
+	">=": ">=",
+	"<":  "<",
+	"<=": "<=",
+}
+
+func jsonText(value any) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("JSON查询参数格式错误：%v", err))
+	}
+	return string(content)
+}
+
+// checkJsonPath 路径只允许 a.b[0].c 格式
+func checkJsonPath(path string, operator string) string {
+	if !jsonPathPattern.MatchString(path) {
+		panic(fmt.Sprintf("JSON路径不合法：%v", path))
+	}
+	op, ok := jsonPathOperators[operator]
+	if !ok {
+		panic(fmt.Sprintf("JSON路径比较运算符不支持：%v", operator))
+	}
+	return op
+}
+
+// sqlLiteral 字符串常量，用于不能使用占位符的场景
+func sqlLiteral(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// placeholders 生成 n 个占位符，a,b,c 展开为 ?,?,?
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -132,6 +132,11 @@ func (s *MysqlAdapter) Book() string {
 	return book.BuildMysqlBook(s.db)
 }
 
+// Er ER图
+func (s *MysqlAdapter) Er(option book.ErOption) string {
+	return book.BuildMysqlEr(s.db, option)
+}
+
 // ModelFiles 生成模型文件
 func (s *MysqlAdapter) ModelFiles(tableNames ...string) (bool, error) {
 	if err := os.MkdirAll("model", 0777); err != nil {
@@ -247,6 +252,79 @@ func (s *MysqlAdapter) QueryWhereFindInSet(db *gorm.DB, input TextSlice, column
 	return db
 }
 
+// QueryWhereJsonContains 包含查询，value 为JSON数组时需包含全部元素，为对象时需包含全部键值
+func (s *MysqlAdapter) QueryWhereJsonContains(db *gorm.DB, column string, value any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	return db.Where(fmt.Sprintf("JSON_CONTAINS(%v, ?)", column), jsonText(value))
+}
+
+// QueryWhereJsonOverlaps 数组包含任一元素
+func (s *MysqlAdapter) QueryWhereJsonOverlaps(db *gorm.DB, column string, values ...any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	if len(values) == 0 {
+		return db
+	}
+	return db.Where(fmt.Sprintf("JSON_OVERLAPS(%v, ?)", column), jsonText(values))
+}
+
+// QueryWhereJsonHasKey 对象存在键
+func (s *MysqlAdapter) QueryWhereJsonHasKey(db *gorm.DB, column string, key string) *gorm.DB {
+	return s.QueryWhereJsonHasAnyKey(db, column, key)
+}
+
+// QueryWhereJsonHasAnyKey 对象存在任一键
+func (s *MysqlAdapter) QueryWhereJsonHasAnyKey(db *gorm.DB, column string, keys ...string) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	if len(keys) == 0 {
+		return db
+	}
+	paths := make([]any, 0, len(keys))
+	for _, key := range keys {
+		paths = append(paths, "$."+jsonText(key))
+	}
+	return db.Where(fmt.Sprintf("JSON_CONTAINS_PATH(%v, 'one', %v)", column, placeholders(len(keys))), paths...)
+}
+
+// QueryWhereJsonPath 路径比较，path 格式 a.b[0].c，operator 支持 = != <> > >= < <=
+func (s *MysqlAdapter) QueryWhereJsonPath(db *gorm.DB, column string, path string, operator string, value any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	checkJsonPath(path, operator)
+	if text, ok := value.(string); ok {
+		return db.Where(fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%v, ?)) %v ?", column, operator), "$."+path, text)
+	}
+	return db.Where(fmt.Sprintf("JSON_EXTRACT(%v, ?) %v CAST(? AS JSON)", column, operator), "$."+path, jsonText(value))
+}
+
+// QueryWhereArrayOverlaps 逗号分隔文本包含任一元素，用于 ctype.Strings 字段
+func (s *MysqlAdapter) QueryWhereArrayOverlaps(db *gorm.DB, column string, values ...any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	if len(values) == 0 {
+		return db
+	}
+	conditions := make([]string, 0, len(values))
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		conditions = append(conditions, fmt.Sprintf("FIND_IN_SET(?, %v)", column))
+		args = append(args, fmt.Sprint(value))
+	}
+	return db.Where(strings.Join(conditions, " OR "), args...)
+}
+
+// Quote 标识符加引号，支持 表.列 格式
+func (s *MysqlAdapter) Quote(name string) string {
+	return quoteIdentifier(name, "`")
+}
+
 func (s *MysqlAdapter) JsonExtract(column string, key string) string {
 	return fmt.Sprintf("JSON_EXTRACT(%v,'$.%v')", column, key)
 }
@@ -9,6 +9,8 @@ package db
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/lgdzz/vingo-utils-v3/pool"
@@ -23,6 +25,9 @@ type PageResult struct {
 	Size  int   `json:"size"`
 	Total int64 `json:"total"`
 	Items any   `json:"items"` // 返回 []T 或 []any
+
+	Summary map[string]any         `json:"summary,omitempty"` // 汇总，QueryOption.Summary 不为空时返回
+	Facets  map[string][]FacetItem `json:"facets,omitempty"`  // 分组计数，QueryOption.Facets 不为空时返回
 }
 
 type PageLimit struct {
@@ -53,7 +58,13 @@ type PageOrder struct {
 	Sort   string `form:"sortOrder"` // asc 或 desc
 }
 
+// HandleColumn 排序规则，使用mysql引号
 func (s *PageOrder) HandleColumn() string {
+	return s.HandleColumnWith(NewMysqlAdapter(nil))
+}
+
+// HandleColumnWith 排序规则，按数据库类型加引号
+func (s *PageOrder) HandleColumnWith(adapter Adapter) string {
 	sort := strings.ToLower(strings.TrimSpace(s.Sort))
 	if sort != "asc" && sort != "desc" {
 		panic("sortOrder 不合法，只允许 asc 或 desc")
@@ -61,15 +72,12 @@ func (s *PageOrder) HandleColumn() string {
 	if strings.ContainsAny(s.Column, " ;--") {
 		panic("sortField 存在 SQL 注入风险")
 	}
-
-	segments := strings.Split(s.Column, ".")
-	for i, seg := range segments {
+	for _, seg := range strings.Split(s.Column, ".") {
 		if seg == "" {
 			panic("字段名非法")
 		}
-		segments[i] = "`" + seg + "`"
 	}
-	return fmt.Sprintf("%s %s", strings.Join(segments, "."), sort)
+	return fmt.Sprintf("%s %s", adapter.Quote(s.Column), sort)
 }
 
 type PageQuery struct {
@@ -80,6 +88,8 @@ type PageQuery struct {
 	LikeColumn    TextSlice `form:"likeColumn"` // 模糊查询列
 	LikeValue     TextSlice `form:"likeValue"`  // 模糊查询值
 	LikeWhitelist *[]string // 模糊查询列白名单
+	Sort          TextSlice `form:"sort"`   // 多字段排序，如 -createdAt,name，- 开头为倒序，需配置 QueryOption.SortMap
+	Fields        TextSlice `form:"fields"` // 返回字段，如 id,name，需配置 QueryOption.FieldMap
 }
 
 type QueryOption[T any] struct {
@@ -92,35 +102,127 @@ type QueryOption[T any] struct {
 	IterateePool func(int, *T)  // 映射函数（协程池）
 	PoolResult   *[]pool.Result // 协程池结果
 	MaxWorkers   int            // 最大协程数
+
+	Summary []Measure // 汇总指标（可选），与分页查询并行计算
+	Facets  []Facet   // 分组计数（可选），与分页查询并行计算
+
+	SortMap  map[string]string // 排序字段映射：公开字段 -> 列名或SQL表达式，设置后排序只允许映射中的字段
+	FieldMap map[string]string // 返回字段白名单：json字段 -> 列名，设置后支持 fields 参数，Iteratee 中只能使用已查询的字段
 }
 
 func (s *QueryOption[T]) BuildOrderString() string {
+	adapter := adapterOf(s.Db)
+	if s.Query.Sort != "" {
+		if order := s.buildSort(adapter); order != "" {
+			return order
+		}
+	}
 	if s.Query.Order == nil && (s.Orders == nil || len(*s.Orders) == 0) {
 		if s.Query.OrderRaw != nil {
 			return *s.Query.OrderRaw
 		}
-		return "`id` desc"
+		return adapter.Quote("id") + " desc"
 	}
 	if s.Query.Order != nil {
-		s.Orders = &[]PageOrder{*s.Query.Order}
+		order := *s.Query.Order
+		if s.SortMap != nil {
+			// 配置映射后前端只能使用公开字段
+			value, ok := s.SortMap[order.Column]
+			if !ok {
+				panic(fmt.Sprintf("sortField 不支持：%v", order.Column))
+			}
+			sort := strings.ToLower(strings.TrimSpace(order.Sort))
+			if sort != "asc" && sort != "desc" {
+				panic("sortOrder 不合法，只允许 asc 或 desc")
+			}
+			return expression(adapter, value) + " " + sort
+		}
+		s.Orders = &[]PageOrder{order}
 	}
 	var orders []string
 	for _, item := range *s.Orders {
-		orders = append(orders, item.HandleColumn())
+		orders = append(orders, item.HandleColumnWith(adapter))
 	}
 	return strings.Join(orders, ", ")
 }
 
-func NewPage[T any](option QueryOption[T]) PageResult {
+// buildSort 解析多字段排序，如 -createdAt,name
+func (s *QueryOption[T]) buildSort(adapter Adapter) string {
+	var orders []string
+	for _, key := range strings.Split(string(s.Query.Sort), ",") {
+		// url中的 + 会被解码为空格，去除空格后按正序处理
+		key = strings.TrimSpace(key)
+		direction := "asc"
+		if strings.HasPrefix(key, "-") {
+			direction = "desc"
+			key = key[1:]
+		} else {
+			key = strings.TrimPrefix(key, "+")
+		}
+		if key == "" {
+			continue
+		}
+		value, ok := s.SortMap[key]
+		if !ok {
+			panic(fmt.Sprintf("sort 字段不支持：%v", key))
+		}
+		orders = append(orders, expression(adapter, value)+" "+direction)
+	}
+	return strings.Join(orders, ", ")
+}
+
+// FieldKeys 解析 fields 参数，字段不在白名单时panic
+func (s *QueryOption[T]) FieldKeys() []string {
+	if s.Query.Fields == "" {
+		return nil
+	}
+	if s.FieldMap == nil {
+		panic("未配置返回字段白名单，不支持 fields 参数")
+	}
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range strings.Split(string(s.Query.Fields), ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		if _, ok := s.FieldMap[key]; !ok {
+			panic(fmt.Sprintf("fields 字段不支持：%v", key))
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// selectFields 只查询 fields 指定的列
+func (s *QueryOption[T]) selectFields(db *gorm.DB) *gorm.DB {
+	keys := s.FieldKeys()
+	if len(keys) == 0 {
+		return db
+	}
+	adapter := adapterOf(db)
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		columns = append(columns, expression(adapter, s.FieldMap[key]))
+	}
+	return db.Select(strings.Join(columns, ", "))
+}
+
+func NewPage[T any](option QueryOption[T]) (result PageResult) {
+	fields := option.FieldKeys()
+
+	// 汇总在计数前复制查询对象，使用相同筛选条件，不含排序分页
+	wait := computeAggregates(option.Db, option.Summary, option.Facets, &result)
+	defer wait()
+
 	var count int64
 	query := option.Db
 	query.Count(&count)
 
-	result := PageResult{
-		Page:  option.Query.Limit.GetPage(),
-		Size:  option.Query.Limit.GetSize(),
-		Total: count,
-	}
+	result.Page = option.Query.Limit.GetPage()
+	result.Size = option.Query.Limit.GetSize()
+	result.Total = count
 
 	if count == 0 {
 		result.Items = []any{}
@@ -161,13 +263,16 @@ func NewPage[T any](option QueryOption[T]) PageResult {
 	} else {
 		result.Items = records
 	}
+	if len(fields) > 0 {
+		result.Items = pickFields(result.Items, fields)
+	}
 	return result
 }
 
 func NewPageNormalHandle[T any](option QueryOption[T], result *PageResult) []T {
 	// 查询数据
 	var records = make([]T, 0, getMinSize(int(result.Total), result.Size))
-	option.Db.Order(option.BuildOrderString()).
+	option.selectFields(option.Db).Order(option.BuildOrderString()).
 		Limit(result.Size).
 		Offset(option.Query.Limit.Offset()).
 		Find(&records)
@@ -175,7 +280,7 @@ func NewPageNormalHandle[T any](option QueryOption[T], result *PageResult) []T {
 }
 
 func NewPageExportHandle[T any](option QueryOption[T], result *PageResult) []T {
-	rows, err := option.Db.
+	rows, err := option.selectFields(option.Db).
 		Order(option.BuildOrderString()).
 		Rows()
 	if err != nil {
@@ -210,3 +315,136 @@ func getMinSize(a, b int) int {
 	}
 	return b
 }
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// quoteIdentifier 按段加引号，a.b -> `a`.`b`
+func quoteIdentifier(name string, quote string) string {
+	segments := strings.Split(name, ".")
+	for i, seg := range segments {
+		if seg == "*" {
+			continue
+		}
+		segments[i] = quote + strings.ReplaceAll(seg, quote, quote+quote) + quote
+	}
+	return strings.Join(segments, ".")
+}
+
+// adapterOf 根据查询对象的数据库类型获取适配器
+func adapterOf(db *gorm.DB) Adapter {
+	if db != nil && db.Dialector != nil && db.Dialector.Name() == "postgres" {
+		return NewPgsqlAdapter(db)
+	}
+	return NewMysqlAdapter(db)
+}
+
+// expression 映射值为列名时加引号，其他情况视为SQL表达式原样使用
+func expression(adapter Adapter, value string) string {
+	if identifierPattern.MatchString(value) {
+		return adapter.Quote(value)
+	}
+	return value
+}
+
+// pickFields 只保留指定的json字段
+// 按字段反射生成只含所选字段的结构体，保留原字段类型和标签，响应时仍按 mask 标签脱敏，大整数也不会丢失精度
+func pickFields(items any, keys []string) any {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		panic("fields 参数只支持对象列表")
+	}
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		panic("fields 参数只支持对象列表")
+	}
+
+	jsonFields := structJsonFields(elem)
+	var (
+		fields  []reflect.StructField
+		indexes [][]int
+		names   = map[string]bool{}
+	)
+	for _, key := range keys {
+		field, ok := jsonFields[key]
+		if !ok {
+			continue
+		}
+		name := field.Name
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%v%d", field.Name, i)
+		}
+		names[name] = true
+		indexes = append(indexes, field.Index)
+		fields = append(fields, reflect.StructField{Name: name, Type: field.Type, Tag: field.Tag})
+	}
+
+	rowType := reflect.StructOf(fields)
+	result := reflect.MakeSlice(reflect.SliceOf(rowType), v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		for item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				break
+			}
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+		row := result.Index(i)
+		for j, index := range indexes {
+			if value, err := item.FieldByIndexErr(index); err == nil {
+				row.Field(j).Set(value)
+			}
+		}
+	}
+	return result.Interface()
+}
+
+// structJsonFields json字段名 -> 字段，Index 为完整下标路径，匿名嵌入的结构体按 JSON 规则展开，外层字段优先
+func structJsonFields(t reflect.Type) map[string]reflect.StructField {
+	result := map[string]reflect.StructField{}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		var embedded []reflect.StructField
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			field.Index = append(append([]int{}, index...), i)
+			if field.Anonymous && name == "" {
+				ft := field.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					embedded = append(embedded, field)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if _, exists := result[name]; !exists {
+				result[name] = field
+			}
+		}
+		for _, field := range embedded {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			walk(ft, field.Index)
+		}
+	}
+	walk(t, nil)
+	return result
+}
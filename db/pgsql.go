@@ -22,6 +22,7 @@ import (
 	"github.com/lgdzz/vingo-utils-v3/db/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -150,6 +151,11 @@ func (s *PgsqlAdapter) Book() string {
 	return book.BuildPgsqlBook(s.db)
 }
 
+// Er ER图
+func (s *PgsqlAdapter) Er(option book.ErOption) string {
+	return book.BuildPgsqlEr(s.db, option)
+}
+
 // ModelFiles 生成模型文件
 func (s *PgsqlAdapter) ModelFiles(tableNames ...string) (bool, error) {
 	if err := os.MkdirAll("model", 0777); err != nil {
@@ -281,6 +287,85 @@ func (s *PgsqlAdapter) QueryWhereFindInSet(db *gorm.DB, input TextSlice, column
 	return db
 }
 
+// QueryWhereJsonContains 包含查询，value 为JSON数组时需包含全部元素，为对象时需包含全部键值
+func (s *PgsqlAdapter) QueryWhereJsonContains(db *gorm.DB, column string, value any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	return db.Where(fmt.Sprintf("%v @> ?::jsonb", column), jsonText(value))
+}
+
+// QueryWhereJsonOverlaps 数组包含任一元素，拆分为多个 @> 条件以使用GIN索引
+func (s *PgsqlAdapter) QueryWhereJsonOverlaps(db *gorm.DB, column string, values ...any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	if len(values) == 0 {
+		return db
+	}
+	conditions := make([]string, 0, len(values))
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		conditions = append(conditions, fmt.Sprintf("%v @> ?::jsonb", column))
+		args = append(args, jsonText([]any{value}))
+	}
+	return db.Where(strings.Join(conditions, " OR "), args...)
+}
+
+// QueryWhereJsonHasKey 对象存在键
+// ? 运算符与占位符冲突，键名以常量写入SQL
+func (s *PgsqlAdapter) QueryWhereJsonHasKey(db *gorm.DB, column string, key string) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	return db.Where(clause.Expr{SQL: fmt.Sprintf("%v ? %v", column, sqlLiteral(key))})
+}
+
+// QueryWhereJsonHasAnyKey 对象存在任一键
+func (s *PgsqlAdapter) QueryWhereJsonHasAnyKey(db *gorm.DB, column string, keys ...string) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	if len(keys) == 0 {
+		return db
+	}
+	literals := make([]string, 0, len(keys))
+	for _, key := range keys {
+		literals = append(literals, sqlLiteral(key))
+	}
+	return db.Where(clause.Expr{SQL: fmt.Sprintf("%v ?| array[%v]", column, strings.Join(literals, ","))})
+}
+
+// QueryWhereJsonPath 路径比较，path 格式 a.b[0].c，operator 支持 = != <> > >= < <=
+func (s *PgsqlAdapter) QueryWhereJsonPath(db *gorm.DB, column string, path string, operator string, value any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	op := checkJsonPath(path, operator)
+	expr := fmt.Sprintf("$.%v ? (@ %v $v)", path, op)
+	return db.Where(fmt.Sprintf("jsonb_path_exists(%v, ?::jsonpath, ?::jsonb)", column), expr, jsonText(map[string]any{"v": value}))
+}
+
+// QueryWhereArrayOverlaps 逗号分隔文本包含任一元素，用于 ctype.Strings 字段
+func (s *PgsqlAdapter) QueryWhereArrayOverlaps(db *gorm.DB, column string, values ...any) *gorm.DB {
+	if db == nil {
+		db = s.db
+	}
+	if len(values) == 0 {
+		return db
+	}
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		args = append(args, fmt.Sprint(value))
+	}
+	return db.Where(fmt.Sprintf("string_to_array(%v, ',') && ARRAY[%v]::text[]", column, placeholders(len(values))), args...)
+}
+
+// Quote 标识符加引号，支持 表.列 格式
+func (s *PgsqlAdapter) Quote(name string) string {
+	return quoteIdentifier(name, `"`)
+}
+
 func (s *PgsqlAdapter) JsonExtract(column string, key string) string {
 	return fmt.Sprintf("(%v->>'%v')::numeric", column, key)
 }
@@ -0,0 +1,256 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：多数据库连接注册表
+//
+// 配置示例：
+//
+//	databases:
+//	  main:
+//	    driver: mysql
+//	    host: 127.0.0.1
+//	    dbname: business
+//	    secret: 0123456789abcdef
+//	  gis:
+//	    driver: pgsql
+//	    dbname: gis
+//	  report:
+//	    host: 10.0.0.8
+//	    dbname: report
+//
+// 用法：
+//
+//	db.Open(config.Databases, "main")
+//	db.Get("report").DB.Find(&rows)
+//	db.Bind(&model.Road{}, "gis") // 或模型实现 Connection() string
+//	db.For(&model.Road{}).DB.Find(&roads)
+// *****************************************************************************
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Databases 按名称配置的多个数据库连接
+type Databases map[string]Config
+
+// Connection 模型实现该接口时，For 返回对应名称的连接
+type Connection interface {
+	Connection() string
+}
+
+type registry struct {
+	mu          sync.RWMutex
+	apis        map[string]*Api
+	names       []string // 注册顺序
+	defaultName string
+	bindings    map[reflect.Type]string
+}
+
+var connections = &registry{apis: map[string]*Api{}, bindings: map[reflect.Type]string{}}
+
+// Open 按配置创建所有连接，defaultName 为空时使用第一个注册的连接作为默认连接
+// 多个连接时按名称排序创建，每个连接使用自己的密文key，全局未设置时使用首个配置 secret 的连接作为全局key
+func Open(configs Databases, defaultName ...string) {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(defaultName) > 0 && defaultName[0] != "" {
+		config, ok := configs[defaultName[0]]
+		if !ok {
+			panic(fmt.Sprintf("默认数据库连接不存在：%v", defaultName[0]))
+		}
+		// 默认连接优先创建，使其 secret 作为全局key
+		Register(defaultName[0], config)
+		SetDefault(defaultName[0])
+	}
+	for _, name := range names {
+		if _, ok := Lookup(name); ok {
+			continue
+		}
+		Register(name, configs[name])
+	}
+}
+
+// Register 创建并注册连接，名称重复时panic
+func Register(name string, config Config) *Api {
+	if name == "" {
+		panic("数据库连接名称不能为空")
+	}
+	if _, ok := Lookup(name); ok {
+		panic(fmt.Sprintf("数据库连接已存在：%v", name))
+	}
+	api := NewDatabase(config)
+	api.Name = name
+	Add(name, api)
+	return api
+}
+
+// Add 注册已创建的连接
+func Add(name string, api *Api) {
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	if _, ok := connections.apis[name]; !ok {
+		connections.names = append(connections.names, name)
+	}
+	api.Name = name
+	connections.apis[name] = api
+	if connections.defaultName == "" {
+		connections.defaultName = name
+	}
+}
+
+// SetDefault 设置默认连接
+func SetDefault(name string) {
+	Get(name)
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	connections.defaultName = name
+}
+
+// Lookup 获取连接
+func Lookup(name string) (*Api, bool) {
+	connections.mu.RLock()
+	defer connections.mu.RUnlock()
+	api, ok := connections.apis[name]
+	return api, ok
+}
+
+// Get 获取连接，不存在时panic
+func Get(name string) *Api {
+	api, ok := Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("数据库连接不存在：%v", name))
+	}
+	return api
+}
+
+// Default 获取默认连接
+func Default() *Api {
+	connections.mu.RLock()
+	name := connections.defaultName
+	connections.mu.RUnlock()
+	if name == "" {
+		panic("未注册数据库连接")
+	}
+	return Get(name)
+}
+
+// Names 所有连接名称，按注册顺序
+func Names() []string {
+	connections.mu.RLock()
+	defer connections.mu.RUnlock()
+	return append([]string{}, connections.names...)
+}
+
+// Bind 绑定模型使用的连接
+func Bind(model any, name string) {
+	Get(name)
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	connections.bindings[modelType(model)] = name
+}
+
+// For 获取模型绑定的连接，优先使用 Connection 接口，其次 Bind 绑定，都没有时返回默认连接
+func For(model any) *Api {
+	if c, ok := model.(Connection); ok {
+		return Get(c.Connection())
+	}
+	connections.mu.RLock()
+	name, ok := connections.bindings[modelType(model)]
+	connections.mu.RUnlock()
+	if ok {
+		return Get(name)
+	}
+	return Default()
+}
+
+func modelType(model any) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+type HealthItem struct {
+	Name    string `json:"name"`
+	Driver  string `json:"driver"`
+	Address string `json:"address"`
+	Ok      bool   `json:"ok"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+	Open    int    `json:"open"` // 当前打开的连接数
+	InUse   int    `json:"inUse"`
+	Idle    int    `json:"idle"`
+}
+
+// Health 检查所有连接
+func Health(timeout ...time.Duration) []HealthItem {
+	t := 3 * time.Second
+	if len(timeout) > 0 {
+		t = timeout[0]
+	}
+	var result []HealthItem
+	for _, name := range Names() {
+		api := Get(name)
+		item := HealthItem{Name: name, Driver: api.Config.Driver, Address: api.Address()}
+		start := time.Now()
+		if err := api.Ping(t); err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Ok = true
+		}
+		item.Latency = time.Since(start).String()
+		if sqlDB, err := api.DB.DB(); err == nil {
+			stats := sqlDB.Stats()
+			item.Open, item.InUse, item.Idle = stats.OpenConnections, stats.InUse, stats.Idle
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// CloseAll 关闭所有连接，等待正在执行的查询完成
+func CloseAll() {
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	for _, name := range connections.names {
+		connections.apis[name].Close()
+	}
+	connections.apis = map[string]*Api{}
+	connections.names = nil
+	connections.defaultName = ""
+	connections.bindings = map[reflect.Type]string{}
+}
+
+// Ping 检查连接
+func (s *Api) Ping(timeout time.Duration) error {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// Close 关闭连接
+func (s *Api) Close() {
+	if sqlDB, err := s.DB.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+// Address 连接地址，用于启动信息和健康检查
+func (s *Api) Address() string {
+	return fmt.Sprintf("%v:%v db:%v", s.Config.Host, s.Config.Port, s.Config.Dbname)
+}
@@ -0,0 +1,182 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：密文字段重新加密
+//
+// 密钥轮换后，按批次扫描配置的表和字段，将非当前密钥加密的密文解密后使用当前密钥重新保存。
+// 更新时附带原密文作为条件，扫描期间被业务修改过的数据会被跳过，不会覆盖新值。
+//
+// 用法：
+//
+//	job := rekey.New(api, rekey.Table{Name: "user", Columns: []string{"password", "id_card"}})
+//	job.Start() // 后台执行，全部完成后自动结束；或 job.Run() 同步执行
+//
+// 注意：主键需为可排序的单列主键
+// *****************************************************************************
+
+package rekey
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/ctype"
+	"github.com/lgdzz/vingo-utils-v3/db"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+	"gorm.io/gorm/clause"
+)
+
+type Table struct {
+	Name       string   // 表名
+	Columns    []string // 密文字段（Ciphertext/Password 类型）
+	PrimaryKey string   // 主键列，默认 id
+	BatchSize  int      // 每批次数量，默认500
+}
+
+type Summary struct {
+	Table    string        `json:"table"`
+	Scanned  int64         `json:"scanned"`  // 扫描行数
+	Rekeyed  int64         `json:"rekeyed"`  // 重新加密的字段数
+	Skipped  int64         `json:"skipped"`  // 扫描期间被修改而跳过的字段数
+	Failed   int64         `json:"failed"`   // 解密失败的字段数
+	Duration time.Duration `json:"duration"` // 耗时
+	Error    string        `json:"error,omitempty"`
+}
+
+type Job struct {
+	api    *db.Api
+	tables []Table
+	Pause  time.Duration // 批次间隔，降低对业务的影响，默认100ms
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 新建重新加密任务
+func New(api *db.Api, tables ...Table) *Job {
+	for i := range tables {
+		t := &tables[i]
+		if t.Name == "" || len(t.Columns) == 0 {
+			panic("重新加密配置不完整：Name、Columns 必填")
+		}
+		if t.PrimaryKey == "" {
+			t.PrimaryKey = "id"
+		}
+		if t.BatchSize <= 0 {
+			t.BatchSize = 500
+		}
+	}
+	return &Job{api: api, tables: tables, Pause: 100 * time.Millisecond}
+}
+
+// Start 后台执行，全部完成或调用 Stop 后结束
+func (s *Job) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.RunContext(ctx)
+	}()
+}
+
+// Stop 停止任务，等待当前批次完成
+func (s *Job) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Run 同步执行
+func (s *Job) Run() []Summary {
+	return s.RunContext(context.Background())
+}
+
+// RunContext 同步执行，ctx 取消后在当前批次完成时结束
+func (s *Job) RunContext(ctx context.Context) []Summary {
+	if !s.mu.TryLock() {
+		return nil
+	}
+	defer s.mu.Unlock()
+
+	var result []Summary
+	for _, t := range s.tables {
+		summary := s.runTable(ctx, t)
+		result = append(result, summary)
+		vingo.LogInfo(fmt.Sprintf("[密文重新加密]表：%v，扫描：%d行，重新加密：%d，跳过：%d，失败：%d，耗时：%v，错误：%v",
+			summary.Table, summary.Scanned, summary.Rekeyed, summary.Skipped, summary.Failed, summary.Duration, summary.Error))
+	}
+	return result
+}
+
+func (s *Job) runTable(ctx context.Context, t Table) (summary Summary) {
+	start := time.Now()
+	summary = Summary{Table: t.Name}
+	defer func() {
+		if r := recover(); r != nil {
+			summary.Error = fmt.Sprintf("%v", r)
+		}
+		summary.Duration = time.Since(start)
+	}()
+
+	var lastKey any
+	for ctx.Err() == nil {
+		rows := s.load(t, lastKey)
+		if len(rows) == 0 {
+			return
+		}
+		for _, row := range rows {
+			s.rekeyRow(t, row, &summary)
+		}
+		summary.Scanned += int64(len(rows))
+		lastKey = rows[len(rows)-1][t.PrimaryKey]
+		if len(rows) < t.BatchSize {
+			return
+		}
+		if s.Pause > 0 {
+			time.Sleep(s.Pause)
+		}
+	}
+	return
+}
+
+func (s *Job) load(t Table, lastKey any) []map[string]any {
+	pk := clause.Column{Name: t.PrimaryKey}
+	columns := append([]string{t.PrimaryKey}, t.Columns...)
+	query := s.api.DB.Table(t.Name).Select(columns)
+	if lastKey != nil {
+		query = query.Where("? > ?", pk, lastKey)
+	}
+	var rows []map[string]any
+	query.Order(clause.OrderByColumn{Column: pk}).Limit(t.BatchSize).Find(&rows)
+	return rows
+}
+
+func (s *Job) rekeyRow(t Table, row map[string]any, summary *Summary) {
+	keys := ctype.KeySetOf(s.api.DB)
+	for _, column := range t.Columns {
+		old := vingo.ToString(row[column])
+		if !keys.NeedRekey(old) {
+			continue
+		}
+		plain, err := keys.Decrypt(old)
+		if err != nil {
+			summary.Failed++
+			vingo.LogError(fmt.Sprintf("[密文重新加密]表：%v，主键：%v，字段：%v，%v", t.Name, row[t.PrimaryKey], column, err))
+			continue
+		}
+		col := clause.Column{Name: column}
+		result := s.api.DB.Table(t.Name).
+			Where("? = ?", clause.Column{Name: t.PrimaryKey}, row[t.PrimaryKey]).
+			Where("? = ?", col, old).
+			UpdateColumn(column, keys.Encrypt(plain))
+		if result.RowsAffected == 0 {
+			summary.Skipped++
+			continue
+		}
+		summary.Rekeyed++
+	}
+}
@@ -0,0 +1,228 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：泛型仓储
+//
+// 表名、主键、软删除由模型结构自动解析，模型包含 gorm.DeletedAt 字段时查询自动排除已删除数据、删除为软删除。
+// WithCtx 传入操作人后，Update/Delete 自动触发 Api.ChangeLog 变更日志。
+//
+// 用法：
+//
+//	var userRepo = db.NewRepo[model.User](api)
+//	user := userRepo.Get(id)
+//	list := userRepo.List(input.PageQuery, func(tx *gorm.DB) *gorm.DB {
+//		return api.QueryWhere(tx, input.Status, "status")
+//	})
+//	userRepo.WithCtx(c).Update(id, func(row *model.User) { row.Name = input.Name })
+//	names := db.Pluck[model.User, string](userRepo, "name")
+// *****************************************************************************
+
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Filter 查询条件
+type Filter func(tx *gorm.DB) *gorm.DB
+
+type Repo[T any] struct {
+	api        *Api
+	tx         *gorm.DB
+	ctx        any
+	unscoped   bool
+	Table      string // 表名
+	PrimaryKey string // 主键列
+	SoftDelete bool   // 是否软删除
+}
+
+// NewRepo 新建仓储
+func NewRepo[T any](api *Api) *Repo[T] {
+	parsed, err := schema.Parse(new(T), &sync.Map{}, api.DB.NamingStrategy)
+	if err != nil {
+		panic(fmt.Sprintf("解析模型失败：%v", err))
+	}
+	repo := &Repo[T]{api: api, Table: parsed.Table}
+	if parsed.PrioritizedPrimaryField != nil {
+		repo.PrimaryKey = parsed.PrioritizedPrimaryField.DBName
+	} else {
+		repo.PrimaryKey = "id"
+	}
+	deletedAt := reflect.TypeOf(gorm.DeletedAt{})
+	for _, field := range parsed.Fields {
+		if field.FieldType == deletedAt {
+			repo.SoftDelete = true
+			break
+		}
+	}
+	return repo
+}
+
+func (s *Repo[T]) clone() *Repo[T] {
+	repo := *s
+	return &repo
+}
+
+// WithCtx 设置操作人，更新和删除时记录变更日志
+func (s *Repo[T]) WithCtx(ctx any) *Repo[T] {
+	repo := s.clone()
+	repo.ctx = ctx
+	return repo
+}
+
+// WithTx 在指定事务中执行
+func (s *Repo[T]) WithTx(tx *gorm.DB) *Repo[T] {
+	repo := s.clone()
+	repo.tx = tx
+	return repo
+}
+
+// Unscoped 包含软删除的数据，Delete 为物理删除
+func (s *Repo[T]) Unscoped() *Repo[T] {
+	repo := s.clone()
+	repo.unscoped = true
+	return repo
+}
+
+// base 写入使用，已设置操作人和软删除范围，不设置模型以免覆盖写入对象
+func (s *Repo[T]) base() *gorm.DB {
+	tx := s.tx
+	if tx == nil {
+		tx = s.api.DB
+	}
+	if s.ctx != nil {
+		tx = tx.Set("ctx", s.ctx)
+	}
+	if s.unscoped {
+		tx = tx.Unscoped()
+	}
+	return tx
+}
+
+// Query 查询对象，已设置模型、操作人和软删除范围
+func (s *Repo[T]) Query(filters ...Filter) *gorm.DB {
+	tx := s.base().Model(new(T))
+	for _, filter := range filters {
+		if filter != nil {
+			tx = filter(tx)
+		}
+	}
+	return tx
+}
+
+func (s *Repo[T]) wherePk(id any) Filter {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(fmt.Sprintf("%v.%v = ?", tx.Statement.Quote(s.Table), tx.Statement.Quote(s.PrimaryKey)), id)
+	}
+}
+
+// Get 根据主键查询，不存在时panic
+func (s *Repo[T]) Get(id any) T {
+	return mustFind[T](s.Query(s.wherePk(id)), false)
+}
+
+// GetBy 根据条件查询第一条，不存在时panic
+func (s *Repo[T]) GetBy(filters ...Filter) T {
+	return mustFind[T](s.Query(filters...), false)
+}
+
+// List 列表查询，分页规则同 QueryList
+func (s *Repo[T]) List(pq PageQuery, filters ...Filter) any {
+	return QueryList[T](s.Query(filters...), pq, nil)
+}
+
+// ListWith 列表查询，支持映射、树形等选项
+func (s *Repo[T]) ListWith(pq PageQuery, option *QueryListOption[T], filters ...Filter) any {
+	return QueryList[T](s.Query(filters...), pq, option)
+}
+
+// All 查询所有符合条件的数据
+func (s *Repo[T]) All(filters ...Filter) []T {
+	var rows = make([]T, 0)
+	s.Query(filters...).Find(&rows)
+	return rows
+}
+
+// Create 新增
+func (s *Repo[T]) Create(row *T) {
+	s.base().Create(row)
+}
+
+// Update 根据主键查询后修改并保存，开启diff，设置操作人时记录变更日志
+func (s *Repo[T]) Update(id any, handler func(row *T)) T {
+	query := s.Query(s.wherePk(id))
+	row := mustFind[T](query, true)
+	handler(&row)
+	s.base().Save(&row)
+	return row
+}
+
+// Updates 按条件批量更新指定字段，不会触发diff
+func (s *Repo[T]) Updates(values map[string]any, filters ...Filter) int64 {
+	if len(filters) == 0 {
+		panic("批量更新必须指定条件")
+	}
+	return s.Query(filters...).Updates(values).RowsAffected
+}
+
+// Delete 根据主键删除，软删除模型为软删除，设置操作人时记录变更日志
+func (s *Repo[T]) Delete(id any) {
+	row := s.Get(id)
+	tx := s.base()
+	tx.Delete(&row)
+	if s.ctx != nil && s.api.ChangeLog != nil {
+		s.api.ChangeLog(tx.Session(&gorm.Session{NewDB: true}), ChangeLogOption{
+			Ctx:             s.ctx,
+			TableName:       s.Table,
+			Description:     vingo.Of("删除数据"),
+			PrimaryKeyValue: id,
+		})
+	}
+}
+
+// Count 统计数量
+func (s *Repo[T]) Count(filters ...Filter) int64 {
+	var count int64
+	s.Query(filters...).Count(&count)
+	return count
+}
+
+// Exists 是否存在
+func (s *Repo[T]) Exists(filters ...Filter) bool {
+	var exists int
+	s.Query(filters...).Select("1").Limit(1).Scan(&exists)
+	return exists == 1
+}
+
+// Chunk 按批次遍历，handler 返回 false 时停止
+func (s *Repo[T]) Chunk(size int, handler func(rows []T) bool, filters ...Filter) {
+	var rows []T
+	s.Query(filters...).FindInBatches(&rows, size, func(tx *gorm.DB, batch int) error {
+		if !handler(rows) {
+			return errChunkStop
+		}
+		return nil
+	})
+}
+
+var errChunkStop = fmt.Errorf("chunk stop")
+
+// Tx 事务，handler 中使用传入的仓储操作
+func (s *Repo[T]) Tx(handler func(repo *Repo[T])) {
+	s.api.FastCommit(func(tx *gorm.DB) {
+		handler(s.WithTx(tx))
+	})
+}
+
+// Pluck 查询单列
+func Pluck[T any, V any](repo *Repo[T], column string, filters ...Filter) []V {
+	var values = make([]V, 0)
+	repo.Query(filters...).Pluck(column, &values)
+	return values
+}
@@ -0,0 +1,341 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：按时间分表
+//
+// 适用于请求日志、登录记录、设备上报等按时间持续增长的数据，
+// 数据按月或按天写入 {基础表名}_{yyyyMM|yyyyMMdd} 分表，分表不存在时根据模型自动创建。
+// 跨分表查询使用 UNION ALL 合并后作为子查询，可直接交给 db.QueryList / db.NewPage 排序分页。
+//
+// 用法：
+//
+//	logShard := shard.New(api, shard.Rule{Base: "log", Model: model.Log{}, Unit: shard.Month, TimeField: "CreatedAt"})
+//	logShard.Insert(api.DB, &row) // 写入 log_202510
+//
+//	query := logShard.QueryRange(input.CreatedAt, "created_at", func(tx *gorm.DB) *gorm.DB {
+//		return api.QueryWhere(tx, input.UserId, "user_id")
+//	})
+//	c.ResponseBody(db.QueryList[model.Log](query, input.PageQuery, nil))
+//
+// 注意：各分表自增主键相互独立，如需全局唯一主键请配合 idgen 雪花ID使用
+// *****************************************************************************
+
+package shard
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/db"
+	ddl "github.com/lgdzz/vingo-utils-v3/db/create"
+	"github.com/lgdzz/vingo-utils-v3/moment"
+	"gorm.io/gorm"
+)
+
+type Unit string
+
+const (
+	Month Unit = "month" // 按月分表，如 log_202510
+	Day   Unit = "day"   // 按天分表，如 log_20251017
+)
+
+// MaxShards 单次查询最多跨越的分表数量，防止时间范围过大（按天分表约3个月）
+var MaxShards = 100
+
+// batchCheck 待确认的分表超过该数量时一次查询全部表名，不再逐个 HasTable
+const batchCheck = 3
+
+type Rule struct {
+	Base      string // 基础表名，如 log
+	Model     any    // 分表模型，用于自动建表
+	Unit      Unit   // 分表粒度，默认按月
+	TimeField string // 模型中的时间字段名，Insert 时据此路由，为空时使用当前时间
+	ModelFile string // 模型文件路径，用于生成字段注释（可选）
+	Comment   string // 表注释（可选）
+}
+
+type Router struct {
+	api    *db.Api
+	rule   Rule
+	tables sync.Map // 已确认存在的分表
+}
+
+// New 新建分表路由
+func New(api *db.Api, rule Rule) *Router {
+	if rule.Base == "" {
+		panic("分表基础表名不能为空")
+	}
+	if rule.Model == nil {
+		panic("分表模型不能为空")
+	}
+	if rule.Unit == "" {
+		rule.Unit = Month
+	}
+	return &Router{api: api, rule: rule}
+}
+
+// Table 根据时间获取分表名
+func (s *Router) Table(t time.Time) string {
+	t = t.Local()
+	switch s.rule.Unit {
+	case Day:
+		return fmt.Sprintf("%v_%v", s.rule.Base, t.Format("20060102"))
+	default:
+		return fmt.Sprintf("%v_%v", s.rule.Base, t.Format("200601"))
+	}
+}
+
+// Tables 获取时间范围内的所有分表名（不判断是否存在），按时间升序
+func (s *Router) Tables(start, end time.Time) []string {
+	if end.Before(start) {
+		start, end = end, start
+	}
+	var tables []string
+	cursor := s.truncate(start)
+	for !cursor.After(end) {
+		tables = append(tables, s.Table(cursor))
+		if len(tables) > MaxShards {
+			panic(fmt.Sprintf("查询时间范围超过%d个分表，请缩小范围", MaxShards))
+		}
+		cursor = s.next(cursor)
+	}
+	return tables
+}
+
+func (s *Router) truncate(t time.Time) time.Time {
+	t = t.Local()
+	if s.rule.Unit == Day {
+		return moment.GetDayFirstMoment(t)
+	}
+	return moment.GetMonthFirstMoment(t)
+}
+
+func (s *Router) next(t time.Time) time.Time {
+	if s.rule.Unit == Day {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// Ensure 确保时间对应的分表存在，不存在则根据模型创建，返回分表名
+func (s *Router) Ensure(t time.Time) string {
+	name := s.Table(t)
+	if _, ok := s.tables.Load(name); ok {
+		return name
+	}
+	ddl.CreateTableIfNotExists(s.api.DB, s.rule.Model, name, s.rule.Comment, s.rule.ModelFile, s.dbType())
+	s.tables.Store(name, struct{}{})
+	return name
+}
+
+// exists 分表是否存在，存在的结果会被缓存
+func (s *Router) exists(name string) bool {
+	if _, ok := s.tables.Load(name); ok {
+		return true
+	}
+	if s.api.DB.Migrator().HasTable(name) {
+		s.tables.Store(name, struct{}{})
+		return true
+	}
+	return false
+}
+
+// existing 过滤出已存在的分表，保持原顺序
+func (s *Router) existing(names []string) []string {
+	var unknown []string
+	for _, name := range names {
+		if _, ok := s.tables.Load(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > batchCheck {
+		if tables, err := s.api.DB.Migrator().GetTables(); err == nil {
+			for _, name := range tables {
+				if s.isShard(name) {
+					s.tables.Store(name, struct{}{})
+				}
+			}
+			unknown = nil
+		}
+	}
+	for _, name := range unknown {
+		s.exists(name)
+	}
+
+	var result []string
+	for _, name := range names {
+		if _, ok := s.tables.Load(name); ok {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// isShard 是否为本规则的分表名
+func (s *Router) isShard(name string) bool {
+	suffix, ok := strings.CutPrefix(name, s.rule.Base+"_")
+	if !ok {
+		return false
+	}
+	layout := "200601"
+	if s.rule.Unit == Day {
+		layout = "20060102"
+	}
+	if len(suffix) != len(layout) {
+		return false
+	}
+	_, err := time.Parse(layout, suffix)
+	return err == nil
+}
+
+// emptyQuery 范围内没有分表时的空结果，使用任意已存在的分表或模型表，查询不会创建分表
+func (s *Router) emptyQuery() *gorm.DB {
+	var name string
+	s.tables.Range(func(key, value any) bool {
+		name = key.(string)
+		return false
+	})
+	if name == "" {
+		if tables, err := s.api.DB.Migrator().GetTables(); err == nil {
+			for _, table := range tables {
+				if s.isShard(table) {
+					name = table
+					s.tables.Store(table, struct{}{})
+					break
+				}
+			}
+		}
+	}
+	if name == "" {
+		return s.api.DB.Model(s.rule.Model).Where("1 = 0")
+	}
+	return s.api.DB.Table(name).Where("1 = 0")
+}
+
+func (s *Router) dbType() string {
+	if s.api.Config.Driver == "pgsql" {
+		return string(ddl.PGSQL)
+	}
+	return string(ddl.MySQL)
+}
+
+// Create 写入指定时间的分表
+func (s *Router) Create(tx *gorm.DB, value any, t time.Time) {
+	if tx == nil {
+		tx = s.api.DB
+	}
+	name := s.Ensure(t)
+	tx.Table(name).Create(value)
+}
+
+// Insert 写入分表，按 Rule.TimeField 字段值路由，字段为空时使用当前时间
+// 支持写入单条记录或切片，切片会按分表分组后批量写入
+func (s *Router) Insert(tx *gorm.DB, value any) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if rv.Kind() != reflect.Slice {
+		s.Create(tx, value, s.timeOf(rv))
+		return
+	}
+
+	groups := map[string]reflect.Value{}
+	var times = map[string]time.Time{}
+	var order []string
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		t := s.timeOf(reflect.Indirect(item))
+		name := s.Table(t)
+		if _, ok := groups[name]; !ok {
+			groups[name] = reflect.MakeSlice(rv.Type(), 0, 0)
+			times[name] = t
+			order = append(order, name)
+		}
+		groups[name] = reflect.Append(groups[name], item)
+	}
+	for _, name := range order {
+		list := reflect.New(rv.Type())
+		list.Elem().Set(groups[name])
+		s.Create(tx, list.Interface(), times[name])
+	}
+}
+
+func (s *Router) timeOf(rv reflect.Value) time.Time {
+	if s.rule.TimeField == "" || rv.Kind() != reflect.Struct {
+		return time.Now()
+	}
+	field := rv.FieldByName(s.rule.TimeField)
+	if !field.IsValid() {
+		panic(fmt.Sprintf("分表模型不存在时间字段：%v", s.rule.TimeField))
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return time.Now()
+		}
+		field = field.Elem()
+	}
+	switch v := field.Interface().(type) {
+	case time.Time:
+		if !v.IsZero() {
+			return v
+		}
+	case moment.LocalTime:
+		if !v.Time().IsZero() {
+			return v.Time()
+		}
+	default:
+		panic(fmt.Sprintf("分表时间字段类型不支持：%T", v))
+	}
+	return time.Now()
+}
+
+// Query 跨分表查询，只合并时间范围内已存在的分表
+// build 用于给每个分表追加相同的查询条件，不要在其中设置排序和分页，排序分页在合并后的结果上执行
+// 返回的查询对象可直接用于 db.QueryList / db.NewPage / Count 等
+func (s *Router) Query(start, end time.Time, build func(tx *gorm.DB) *gorm.DB) *gorm.DB {
+	var parts []string
+	var subs []any
+	for _, name := range s.existing(s.Tables(start, end)) {
+		sub := s.api.DB.Table(name)
+		if build != nil {
+			sub = build(sub)
+		}
+		parts = append(parts, "(?)")
+		subs = append(subs, sub)
+	}
+
+	if len(subs) == 0 {
+		// 范围内没有分表时返回空结果，保证后续分页逻辑正常；查询时不建表
+		parts = append(parts, "(?)")
+		subs = append(subs, s.emptyQuery())
+	}
+
+	if len(subs) == 1 {
+		return s.api.DB.Table("(?) AS shard_union", subs[0])
+	}
+	return s.api.DB.Table("(?) AS shard_union", gorm.Expr(strings.Join(parts, " UNION ALL "), subs...))
+}
+
+// QueryRange 按日期范围跨分表查询，同时在各分表内追加 column 的时间范围条件
+// 范围的起止可以为空，为空时分别默认为当前分表的开始和当前时间
+func (s *Router) QueryRange(input moment.DateTextRange, column string, build func(tx *gorm.DB) *gorm.DB) *gorm.DB {
+	start := s.truncate(time.Now())
+	end := time.Now()
+	if input != "" {
+		startText, endText := input.ToBetween()
+		if startText != nil {
+			start = startText.ToTime()
+		}
+		if endText != nil {
+			end = endText.ToTime()
+		}
+	}
+	return s.Query(start, end, func(tx *gorm.DB) *gorm.DB {
+		tx = s.api.QueryWhereDate(tx, input, column)
+		if build != nil {
+			tx = build(tx)
+		}
+		return tx
+	})
+}
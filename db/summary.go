@@ -0,0 +1,270 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：分页汇总和分组计数
+//
+// 在分页查询的同一筛选条件上（不含排序分页）计算合计行和分类计数，与分页查询并行执行：
+//
+//	db.NewPage(db.QueryOption[model.Order]{
+//		Db:    query,
+//		Query: input.PageQuery,
+//		Summary: []db.Measure{
+//			{Name: "amount", Func: db.Sum, Column: "amount"},
+//			{Name: "paidCount", Func: db.Count, Where: "status = 2"},
+//		},
+//		Facets: []db.Facet{{Name: "status", Column: "status"}},
+//	})
+//
+// 返回：{"summary": {"amount": 1280.5, "paidCount": 12}, "facets": {"status": [{"value": 1, "count": 3}]}}
+// 注意：Column、Where 为SQL表达式，不要拼接用户输入
+// *****************************************************************************
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+const (
+	Sum      = "sum"
+	Count    = "count"
+	Avg      = "avg"
+	Max      = "max"
+	Min      = "min"
+	Distinct = "distinct" // 去重计数
+)
+
+// Measure 汇总指标
+type Measure struct {
+	Name   string // 返回字段名
+	Func   string // 聚合方式：sum|count|avg|max|min|distinct
+	Column string // 列名或表达式，count 时可为空
+	Where  string // 条件，只统计满足条件的数据（可选）
+}
+
+// Facet 分组计数
+type Facet struct {
+	Name   string // 返回字段名，默认为 Column
+	Column string // 分组列
+	Limit  int    // 最多返回的分组数，按数量倒序，默认50
+}
+
+type FacetItem struct {
+	Value any   `json:"value"` // 分组值，NULL 时为 null
+	Count int64 `json:"count"`
+}
+
+// expr 生成聚合表达式
+func (s Measure) expr() string {
+	column := s.Column
+	if s.Where != "" {
+		switch s.Func {
+		case Count:
+			return fmt.Sprintf("SUM(CASE WHEN %v THEN 1 ELSE 0 END)", s.Where)
+		case Sum:
+			return fmt.Sprintf("SUM(CASE WHEN %v THEN %v ELSE 0 END)", s.Where, column)
+		default:
+			// avg/max/min/distinct 条件不满足时为NULL，不参与计算
+			column = fmt.Sprintf("CASE WHEN %v THEN %v END", s.Where, column)
+		}
+	}
+	switch s.Func {
+	case Count:
+		if column == "" {
+			return "COUNT(*)"
+		}
+		return fmt.Sprintf("COUNT(%v)", column)
+	case Sum:
+		return fmt.Sprintf("COALESCE(SUM(%v), 0)", column)
+	case Avg:
+		return fmt.Sprintf("AVG(%v)", column)
+	case Max:
+		return fmt.Sprintf("MAX(%v)", column)
+	case Min:
+		return fmt.Sprintf("MIN(%v)", column)
+	case Distinct:
+		return fmt.Sprintf("COUNT(DISTINCT %v)", column)
+	default:
+		panic(fmt.Sprintf("汇总方式不支持：%v", s.Func))
+	}
+}
+
+// QuerySummary 计算汇总
+func QuerySummary(db *gorm.DB, measures []Measure) map[string]any {
+	expr := make([]string, 0, len(measures))
+	for _, m := range measures {
+		if m.Name == "" {
+			panic("汇总指标 Name 不能为空")
+		}
+		expr = append(expr, fmt.Sprintf("%v AS %v", m.expr(), db.Statement.Quote(m.Name)))
+	}
+	var row = map[string]any{}
+	db.Select(strings.Join(expr, ", ")).Limit(1).Scan(&row)
+
+	result := make(map[string]any, len(measures))
+	for _, m := range measures {
+		result[m.Name] = normalizeNumber(row[m.Name])
+	}
+	return result
+}
+
+// QueryFacets 计算分组计数
+func QueryFacets(db *gorm.DB, facets []Facet) map[string][]FacetItem {
+	result := make(map[string][]FacetItem, len(facets))
+	for _, f := range facets {
+		name, items := queryFacet(db, f)
+		result[name] = items
+	}
+	return result
+}
+
+func queryFacet(db *gorm.DB, f Facet) (string, []FacetItem) {
+	if f.Column == "" {
+		panic("分组计数 Column 不能为空")
+	}
+	if f.Name == "" {
+		f.Name = f.Column
+	}
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	var rows []map[string]any
+	db.Select(fmt.Sprintf("%v AS facet_value, COUNT(*) AS facet_count", f.Column)).
+		Group(f.Column).
+		Order("facet_count DESC").
+		Limit(f.Limit).
+		Scan(&rows)
+
+	items := make([]FacetItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, FacetItem{
+			Value: facetValue(row["facet_value"]),
+			Count: int64(toFloat(row["facet_count"])),
+		})
+	}
+	return f.Name, items
+}
+
+// normalizeNumber 汇总结果转为数字，mysql 的 decimal/聚合结果返回 []byte 或 string，NULL（无数据）为0
+func normalizeNumber(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case []byte:
+		return parseNumber(string(v))
+	case string:
+		return parseNumber(v)
+	}
+	return value
+}
+
+func parseNumber(value string) any {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// facetValue 分组值保留原类型，NULL 返回 nil，字符串列不转为数字，避免 "00123" 等编码丢失前导0
+func facetValue(value any) any {
+	if v, ok := value.([]byte); ok {
+		return string(v)
+	}
+	return value
+}
+
+func toFloat(value any) float64 {
+	switch v := normalizeNumber(value).(type) {
+	case int64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+// isolate 复制查询对象，复制后的对象可以在其他协程中独立使用
+func isolate(db *gorm.DB) *gorm.DB {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.Session(&gorm.Session{Context: ctx})
+}
+
+// inTransaction 事务中同一连接不能并发查询
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// computeAggregates 并行计算汇总和分组计数，返回等待函数
+func computeAggregates(db *gorm.DB, measures []Measure, facets []Facet, result *PageResult) func() {
+	if len(measures) == 0 && len(facets) == 0 {
+		return func() {}
+	}
+
+	var tasks []func()
+	if len(measures) > 0 {
+		query := isolate(db)
+		tasks = append(tasks, func() {
+			result.Summary = QuerySummary(query, measures)
+		})
+	}
+	if len(facets) > 0 {
+		result.Facets = make(map[string][]FacetItem, len(facets))
+		var mu sync.Mutex
+		for _, f := range facets {
+			query := isolate(db)
+			tasks = append(tasks, func() {
+				name, items := queryFacet(query, f)
+				mu.Lock()
+				result.Facets[name] = items
+				mu.Unlock()
+			})
+		}
+	}
+
+	if inTransaction(db) {
+		return func() {
+			for _, task := range tasks {
+				task()
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var failure any
+	for _, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() { failure = r })
+				}
+			}()
+			task()
+		}()
+	}
+	return func() {
+		wg.Wait()
+		if failure != nil {
+			panic(failure)
+		}
+	}
+}
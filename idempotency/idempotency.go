@@ -0,0 +1,181 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：接口幂等
+//
+// 客户端在请求头 Idempotency-Key 中携带唯一值（如UUID），网络重试时使用相同的值：
+//   - 首次请求加锁处理，处理完成后保存响应（状态码、error、message、data）
+//   - 重复请求直接返回保存的响应，响应头 Idempotent-Replayed: true
+//   - 处理中的重复请求返回409
+//   - 同一个 Idempotency-Key 携带不同的请求体返回422
+//
+// 业务异常（panic）不保存响应，客户端可使用相同的值重试
+//
+//	idem := idempotency.Middleware(idempotency.Option{Redis: redisApi})
+//	vingo.RoutesPost(g, "/order.create", order.Create, idem)
+// *****************************************************************************
+
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/go-redis/redis"
+	"github.com/lgdzz/vingo-utils-v3/redis"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+const HeaderKey = "Idempotency-Key"
+
+type Option struct {
+	Redis       *redis.Api
+	TTL         time.Duration                 // 响应保存时长，默认24小时
+	LockTimeout time.Duration                 // 处理锁超时，应大于接口最长处理时间，默认1分钟
+	Required    bool                          // true时缺少 Idempotency-Key 的请求返回400
+	Scope       func(c *vingo.Context) string // 区分不同调用方，默认按用户ID，未登录时按IP
+}
+
+// record 保存的响应
+type record struct {
+	Hash    string          `json:"hash"`
+	Status  int             `json:"status"`
+	Error   int             `json:"error"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// unlockScript 只释放自己持有的锁
+var unlockScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+func defaultScope(c *vingo.Context) string {
+	if id := c.GetUserId(); id > 0 {
+		return fmt.Sprintf("user:%d", id)
+	}
+	return "ip:" + c.GetRealClientIP()
+}
+
+// Middleware 幂等中间件
+func Middleware(option Option) gin.HandlerFunc {
+	if option.Redis == nil {
+		panic("幂等校验需要配置redis")
+	}
+	if option.TTL <= 0 {
+		option.TTL = 24 * time.Hour
+	}
+	if option.LockTimeout <= 0 {
+		option.LockTimeout = time.Minute
+	}
+	if option.Scope == nil {
+		option.Scope = defaultScope
+	}
+	api := option.Redis
+
+	return func(c *gin.Context) {
+		ctx := &vingo.Context{Context: c}
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			if option.Required {
+				reject(ctx, http.StatusBadRequest, "缺少请求头 "+HeaderKey)
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(key) > 128 {
+			reject(ctx, http.StatusBadRequest, HeaderKey+" 长度不能超过128")
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			panic(err.Error())
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		redisKey := api.Config.Prefix + "idempotency:" + option.Scope(ctx) + ":" + c.Request.URL.Path + ":" + key
+		lockKey := redisKey + ":lock"
+
+		if replay(ctx, api, redisKey, hash) {
+			return
+		}
+
+		token := vingo.GetUUID()
+		ok, err := api.Client.SetNX(lockKey, token, option.LockTimeout).Result()
+		if err != nil {
+			panic(fmt.Sprintf("幂等锁获取失败：%v", err))
+		}
+		if !ok {
+			// 加锁前刚好处理完成
+			if replay(ctx, api, redisKey, hash) {
+				return
+			}
+			reject(ctx, http.StatusConflict, "请求处理中，请勿重复提交")
+			return
+		}
+		defer unlockScript.Run(api.Client, []string{lockKey}, token)
+
+		c.Next()
+
+		d := ctx.GetResponseData()
+		if d == nil || d.Status >= http.StatusInternalServerError {
+			return
+		}
+		data, err := json.Marshal(d.Data)
+		if err != nil {
+			vingo.LogError(fmt.Sprintf("[幂等]响应序列化失败：%v", err))
+			return
+		}
+		value, _ := json.Marshal(record{Hash: hash, Status: d.Status, Error: d.Error, Message: d.Message, Data: data})
+		if err = api.Client.Set(redisKey, value, option.TTL).Err(); err != nil {
+			vingo.LogError(fmt.Sprintf("[幂等]响应保存失败：%v", err))
+		}
+	}
+}
+
+// replay 已有保存的响应时返回该响应
+func replay(ctx *vingo.Context, api *redis.Api, redisKey string, hash string) bool {
+	value, err := api.Client.Get(redisKey).Bytes()
+	if err == goredis.Nil {
+		return false
+	}
+	if err != nil {
+		panic(fmt.Sprintf("幂等记录读取失败：%v", err))
+	}
+	var saved record
+	if err = json.Unmarshal(value, &saved); err != nil {
+		vingo.LogError(fmt.Sprintf("[幂等]记录解析失败：%v", err))
+		return false
+	}
+	if saved.Hash != hash {
+		reject(ctx, http.StatusUnprocessableEntity, HeaderKey+" 已用于其他请求内容")
+		return true
+	}
+	ctx.Header("Idempotent-Replayed", "true")
+	var data any
+	if len(saved.Data) > 0 && string(saved.Data) != "null" {
+		data = saved.Data
+	}
+	ctx.Response(&vingo.ResponseData{Status: saved.Status, Error: saved.Error, Message: saved.Message, Data: data})
+	ctx.Abort()
+	return true
+}
+
+func reject(ctx *vingo.Context, status int, message string) {
+	ctx.Response(&vingo.ResponseData{Status: status, Error: 1, ErrorType: "幂等校验", Message: message})
+	ctx.Abort()
+}
@@ -0,0 +1,88 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：gorm创建插件，自动填充id标签字段
+//
+// id:"snowflake" 整型字段为零值时填充雪花ID
+// id:"seq=ORD"   字符串字段为空时填充业务流水号
+// *****************************************************************************
+
+package idgen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lgdzz/vingo-utils-v3/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// RegisterCallback 注册创建插件，未指定生成器时使用 idgen.Default
+func RegisterCallback(api *db.Api, generator ...*Generator) {
+	var g *Generator
+	if len(generator) > 0 {
+		g = generator[0]
+	}
+	err := api.DB.Callback().Create().Before("gorm:before_create").Register("vingo:idgen", func(tx *gorm.DB) {
+		if tx.Statement.Schema == nil {
+			return
+		}
+		current := g
+		if current == nil {
+			current = Default
+		}
+		fill(tx, current)
+	})
+	if err != nil {
+		panic(fmt.Sprintf("插件注册失败: %v", err.Error()))
+	}
+}
+
+func fill(tx *gorm.DB, g *Generator) {
+	var fields []*schema.Field
+	for _, field := range tx.Statement.Schema.Fields {
+		if field.Tag.Get("id") != "" {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	if g == nil {
+		panic("idgen 未初始化，请先执行 idgen.Init")
+	}
+
+	rv := tx.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fillRow(tx, g, fields, reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		fillRow(tx, g, fields, rv)
+	}
+}
+
+func fillRow(tx *gorm.DB, g *Generator, fields []*schema.Field, row reflect.Value) {
+	ctx := tx.Statement.Context
+	for _, field := range fields {
+		if _, isZero := field.ValueOf(ctx, row); !isZero {
+			continue
+		}
+		tag := field.Tag.Get("id")
+		var value any
+		switch {
+		case tag == "snowflake":
+			value = g.NextId()
+		case strings.HasPrefix(tag, "seq="):
+			value = g.NextSeq(strings.TrimPrefix(tag, "seq="))
+		default:
+			panic(fmt.Sprintf("字段[%v]id标签不合法：%v", field.Name, tag))
+		}
+		if err := field.Set(ctx, row, value); err != nil {
+			panic(fmt.Sprintf("字段[%v]填充ID失败：%v", field.Name, err))
+		}
+	}
+}
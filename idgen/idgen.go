@@ -0,0 +1,107 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：分布式ID
+//
+// 用法：
+//
+//	idgen.Init(idgen.Config{RedisApi: redisApi})
+//	idgen.RegisterCallback(dbApi) // 创建记录时自动填充 id 标签字段
+//
+//	type Order struct {
+//		Id      int64  `gorm:"primaryKey;column:id" id:"snowflake"`
+//		OrderNo string `gorm:"column:order_no" id:"seq=ORD"` // ORD20251017000123
+//	}
+// *****************************************************************************
+
+package idgen
+
+import (
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/redis"
+)
+
+type Config struct {
+	RedisApi       *redis.Api    // redis操作对象
+	Epoch          time.Time     // 雪花ID起始时间，默认2025-01-01，上线后不可修改
+	WorkerId       *int64        // 指定机器号时不再从redis租用（单实例或固定部署）
+	LeaseTTL       time.Duration // 机器号租约有效期，默认30秒
+	Heartbeat      time.Duration // 机器号续期间隔，默认10秒
+	SequenceWidth  int           // 业务流水号位数，默认6位
+	SequenceLayout string        // 业务流水号日期格式，默认20060102
+}
+
+type Generator struct {
+	Config    Config
+	snowflake *Snowflake
+	lease     *WorkerLease
+}
+
+var Default *Generator
+
+// Init 初始化默认生成器（只需要执行1次）
+func Init(config Config) *Generator {
+	Default = NewGenerator(config)
+	return Default
+}
+
+// NewGenerator 新建ID生成器
+func NewGenerator(config Config) *Generator {
+	if config.SequenceWidth <= 0 {
+		config.SequenceWidth = 6
+	}
+	if config.SequenceLayout == "" {
+		config.SequenceLayout = "20060102"
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = 30 * time.Second
+	}
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = 10 * time.Second
+	}
+
+	g := &Generator{Config: config}
+	if config.WorkerId != nil {
+		g.snowflake = NewSnowflake(*config.WorkerId, config.Epoch)
+	} else {
+		g.lease = NewWorkerLease(config.RedisApi, config.LeaseTTL, config.Heartbeat)
+		g.snowflake = NewSnowflake(g.lease.WorkerId(), config.Epoch)
+		g.snowflake.valid = g.lease.Alive
+	}
+	return g
+}
+
+// NextId 生成雪花ID
+func (s *Generator) NextId() int64 {
+	return s.snowflake.NextId()
+}
+
+// Snowflake 雪花ID生成器
+func (s *Generator) Snowflake() *Snowflake {
+	return s.snowflake
+}
+
+// Close 释放机器号租约
+func (s *Generator) Close() {
+	if s.lease != nil {
+		s.lease.Release()
+	}
+}
+
+// NextId 使用默认生成器生成雪花ID
+func NextId() int64 {
+	return mustDefault().NextId()
+}
+
+// NextSeq 使用默认生成器生成业务流水号
+func NextSeq(prefix string) string {
+	return mustDefault().NextSeq(prefix)
+}
+
+func mustDefault() *Generator {
+	if Default == nil {
+		panic("idgen 未初始化，请先执行 idgen.Init")
+	}
+	return Default
+}
@@ -0,0 +1,35 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：业务流水号
+//
+// 格式：前缀 + 日期 + 按天自增序号，如 ORD20251017000123
+// 序号由 redis INCR 生成，每天一个key，跨实例全局唯一，无需查库校验
+// *****************************************************************************
+
+package idgen
+
+import (
+	"fmt"
+	"time"
+)
+
+// NextSeq 生成业务流水号
+func (s *Generator) NextSeq(prefix string) string {
+	if s.Config.RedisApi == nil {
+		panic("业务流水号需要redis连接")
+	}
+	date := time.Now().Format(s.Config.SequenceLayout)
+	key := fmt.Sprintf("%vidgen.seq.%v.%v", s.Config.RedisApi.Config.Prefix, prefix, date)
+
+	client := s.Config.RedisApi.Client
+	seq, err := client.Incr(key).Result()
+	if err != nil {
+		panic(fmt.Sprintf("生成业务流水号失败: %v", err))
+	}
+	if seq == 1 {
+		// 当天第一个序号，设置过期时间，多保留一天避免跨天边界问题
+		client.Expire(key, 48*time.Hour)
+	}
+	return fmt.Sprintf("%v%v%0*d", prefix, date, s.Config.SequenceWidth, seq)
+}
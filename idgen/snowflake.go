@@ -0,0 +1,102 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：雪花ID
+//
+// 结构：1位符号位 + 41位毫秒时间戳 + 10位机器号 + 12位序列号
+// 机器号通过 WorkerLease 从 redis 租用，保证多实例之间不冲突
+// *****************************************************************************
+
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	workerBits   = 10
+	sequenceBits = 12
+
+	MaxWorkerId  = -1 ^ (-1 << workerBits)   // 1023
+	maxSequence  = -1 ^ (-1 << sequenceBits) // 4095
+	workerShift  = sequenceBits
+	timeShift    = sequenceBits + workerBits
+	maxClockBack = 10 * time.Millisecond // 允许等待的最大时钟回拨
+)
+
+// DefaultEpoch 默认起始时间 2025-01-01 00:00:00 UTC
+var DefaultEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type Snowflake struct {
+	mu       sync.Mutex
+	epoch    int64 // 起始时间（毫秒）
+	workerId int64
+	lastTime int64
+	sequence int64
+	valid    func() bool // 机器号是否仍然有效
+}
+
+// NewSnowflake 新建雪花ID生成器
+func NewSnowflake(workerId int64, epoch time.Time) *Snowflake {
+	if workerId < 0 || workerId > MaxWorkerId {
+		panic(fmt.Sprintf("雪花ID机器号范围为0-%d", MaxWorkerId))
+	}
+	if epoch.IsZero() {
+		epoch = DefaultEpoch
+	}
+	return &Snowflake{
+		epoch:    epoch.UnixMilli(),
+		workerId: workerId,
+	}
+}
+
+// WorkerId 当前机器号
+func (s *Snowflake) WorkerId() int64 {
+	return s.workerId
+}
+
+// NextId 生成下一个ID
+func (s *Snowflake) NextId() int64 {
+	if s.valid != nil && !s.valid() {
+		panic("雪花ID机器号租约已失效或续期超时，暂停生成")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	if now < s.lastTime {
+		// 时钟回拨，小范围内等待追上，否则拒绝生成
+		back := time.Duration(s.lastTime-now) * time.Millisecond
+		if back > maxClockBack {
+			panic(fmt.Sprintf("系统时钟回拨%v，拒绝生成雪花ID", back))
+		}
+		time.Sleep(back)
+		now = time.Now().UnixMilli()
+	}
+
+	if now == s.lastTime {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			// 当前毫秒序列号用完，等待下一毫秒
+			for now <= s.lastTime {
+				now = time.Now().UnixMilli()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTime = now
+
+	return (now-s.epoch)<<timeShift | s.workerId<<workerShift | s.sequence
+}
+
+// Parse 解析雪花ID，返回生成时间、机器号、序列号
+func (s *Snowflake) Parse(id int64) (time.Time, int64, int64) {
+	ms := (id >> timeShift) + s.epoch
+	workerId := (id >> workerShift) & MaxWorkerId
+	sequence := id & maxSequence
+	return time.UnixMilli(ms), workerId, sequence
+}
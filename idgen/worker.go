@@ -0,0 +1,160 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：雪花ID机器号租约
+//
+// 启动时从 redis 中抢占一个空闲机器号（SETNX + 过期时间），
+// 之后定时心跳续期；距上次成功续期接近有效期（redis 长时间不可用）时生成器暂停，
+// 恢复后重新取得同一机器号再继续；机器号被其他实例占用时生成器停止工作
+// *****************************************************************************
+
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/redis"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+// 仅当机器号仍归属当前实例时续期
+const renewScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+
+// 仅当机器号仍归属当前实例时释放
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+type WorkerLease struct {
+	redisApi  *redis.Api
+	workerId  int64
+	owner     string
+	ttl       time.Duration
+	heartbeat time.Duration
+	mu        sync.RWMutex
+	alive     bool
+	lastRenew time.Time // 上次成功续期（或租用）的请求发起时间，key 最早在 lastRenew+ttl 过期
+	stop      chan struct{}
+	once      sync.Once
+}
+
+// NewWorkerLease 从 redis 租用机器号
+// ttl-租约有效期，heartbeat-续期间隔（应明显小于ttl）
+func NewWorkerLease(redisApi *redis.Api, ttl time.Duration, heartbeat time.Duration) *WorkerLease {
+	if redisApi == nil {
+		panic("机器号租约需要redis连接")
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	if heartbeat <= 0 || heartbeat >= ttl {
+		heartbeat = ttl / 3
+	}
+
+	lease := &WorkerLease{
+		redisApi:  redisApi,
+		owner:     vingo.GetUUID(),
+		ttl:       ttl,
+		heartbeat: heartbeat,
+		stop:      make(chan struct{}),
+	}
+
+	for id := int64(0); id <= MaxWorkerId; id++ {
+		start := time.Now()
+		ok, err := redisApi.Client.SetNX(lease.key(id), lease.owner, ttl).Result()
+		if err != nil {
+			panic(fmt.Sprintf("租用雪花ID机器号失败: %v", err))
+		}
+		if ok {
+			lease.workerId = id
+			lease.alive = true
+			lease.lastRenew = start
+			go lease.keepalive()
+			return lease
+		}
+	}
+	panic(fmt.Sprintf("雪花ID机器号已全部被占用（0-%d）", MaxWorkerId))
+}
+
+func (s *WorkerLease) key(id int64) string {
+	return fmt.Sprintf("%vidgen.worker.%d", s.redisApi.Config.Prefix, id)
+}
+
+// WorkerId 租用到的机器号
+func (s *WorkerLease) WorkerId() int64 {
+	return s.workerId
+}
+
+// Alive 租约是否有效，距上次成功续期超过有效期减去安全余量时视为无效，避免 key 过期后被其他实例租用造成ID重复
+func (s *WorkerLease) Alive() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.alive && time.Since(s.lastRenew) < s.ttl-s.margin()
+}
+
+// margin 安全余量，覆盖续期请求耗时和各节点时钟误差
+func (s *WorkerLease) margin() time.Duration {
+	return s.ttl / 5
+}
+
+func (s *WorkerLease) keepalive() {
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.renew()
+		}
+	}
+}
+
+func (s *WorkerLease) renew() {
+	start := time.Now()
+	result, err := s.redisApi.Client.Eval(renewScript, []string{s.key(s.workerId)}, s.owner, s.ttl.Milliseconds()).Int64()
+	if err != nil {
+		// 网络抖动时继续重试，距上次成功续期接近有效期后 Alive 返回false，生成器暂停
+		vingo.LogError(fmt.Sprintf("[雪花ID]机器号%d续期失败：%v", s.workerId, err))
+		return
+	}
+	if result == 0 {
+		// key 已过期，未被其他实例占用时重新租用同一机器号
+		ok, err := s.redisApi.Client.SetNX(s.key(s.workerId), s.owner, s.ttl).Result()
+		if err != nil {
+			vingo.LogError(fmt.Sprintf("[雪花ID]机器号%d重新租用失败：%v", s.workerId, err))
+			return
+		}
+		if !ok {
+			s.mu.Lock()
+			s.alive = false
+			s.mu.Unlock()
+			vingo.LogError(fmt.Sprintf("[雪花ID]机器号%d租约已丢失，停止生成ID", s.workerId))
+			s.Release()
+			return
+		}
+		vingo.LogInfo(fmt.Sprintf("[雪花ID]机器号%d已重新租用", s.workerId))
+	}
+	s.mu.Lock()
+	s.lastRenew = start
+	s.mu.Unlock()
+}
+
+// Release 释放机器号，停止心跳
+func (s *WorkerLease) Release() {
+	s.once.Do(func() {
+		close(s.stop)
+		_ = s.redisApi.Client.Eval(releaseScript, []string{s.key(s.workerId)}, s.owner).Err()
+		s.mu.Lock()
+		s.alive = false
+		s.mu.Unlock()
+	})
+}
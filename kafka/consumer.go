@@ -9,8 +9,10 @@ package kafka
 import (
 	"context"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/lgdzz/vingo-utils-v3/vingo"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
 )
@@ -19,6 +21,8 @@ type Consumer struct {
 	config *Config
 	reader *kafka.Reader
 	cancel context.CancelFunc
+	done   chan struct{} // 消费循环已退出
+	once   sync.Once
 }
 
 // NewConsumer 初始化消费者
@@ -44,18 +48,22 @@ func NewConsumer(config *Config, groupId string, startOffset int64) *Consumer {
 		MaxBytes:    10e6, // 10MB
 	})
 
-	return &Consumer{
+	consumer := &Consumer{
 		config: config,
 		reader: reader,
 	}
+	vingo.RegisterShutdown("kafka消费者:"+config.Topic, vingo.ShutdownConsumer, consumer.Shutdown)
+	return consumer
 }
 
 // Start 启动消费循环，自动重连，每 10 秒重试一次
 func (s *Consumer) Start(handler func(msg []byte) error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel // 保存 cancel，Close 时可以调用
+	s.done = make(chan struct{})
 
 	go func() {
+		defer close(s.done)
 		for {
 			select {
 			case <-ctx.Done():
@@ -93,7 +101,8 @@ func (s *Consumer) consumeLoop(ctx context.Context, handler func(msg []byte) err
 			log.Println("received message:", string(m.Value))
 		}
 
-		if err := s.reader.CommitMessages(ctx, m); err != nil {
+		// 停止时当前消息已处理完成，仍需提交
+		if err := s.reader.CommitMessages(context.WithoutCancel(ctx), m); err != nil {
 			return err
 		}
 	}
@@ -101,8 +110,23 @@ func (s *Consumer) consumeLoop(ctx context.Context, handler func(msg []byte) err
 
 // Close 关闭消费者，释放连接
 func (s *Consumer) Close() error {
-	if s.cancel != nil {
-		s.cancel()
-	}
-	return s.reader.Close()
+	return s.Shutdown(context.Background())
+}
+
+// Shutdown 停止拉取消息，等待当前消息处理并提交后关闭，ctx 超时时直接关闭
+func (s *Consumer) Shutdown(ctx context.Context) error {
+	var err error
+	s.once.Do(func() {
+		if s.cancel != nil {
+			s.cancel()
+		}
+		if s.done != nil {
+			select {
+			case <-s.done:
+			case <-ctx.Done():
+			}
+		}
+		err = s.reader.Close()
+	})
+	return err
 }
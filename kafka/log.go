@@ -0,0 +1,52 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：日志输出到 kafka
+//
+//	vingo.AddLogSink(kafka.NewLogSink(&kafka.Config{Broker: "127.0.0.1:9092", Topic: "app-log"}))
+// *****************************************************************************
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// LogSink 异步批量发送日志，停机时由日志服务在写入剩余日志后关闭
+type LogSink struct {
+	writer *kafka.Writer
+	once   sync.Once
+}
+
+// NewLogSink 创建 kafka 日志输出，每条日志为一条消息
+func NewLogSink(config *Config) *LogSink {
+	writer := newWriter(config)
+	writer.Async = true
+	writer.BatchTimeout = time.Second
+	writer.Completion = func(messages []kafka.Message, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error sending %d logs to kafka: %v\n", len(messages), err)
+		}
+	}
+	return &LogSink{writer: writer}
+}
+
+func (s *LogSink) Write(line []byte) error {
+	// 异步模式下 WriteMessages 立即返回，每条日志的 line 都是新分配的，无需复制
+	return s.writer.WriteMessages(context.Background(), kafka.Message{Value: line})
+}
+
+// Close 发送缓冲中的日志后关闭连接
+func (s *LogSink) Close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.writer.Close()
+	})
+	return err
+}
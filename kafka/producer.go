@@ -10,8 +10,10 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"sync"
 	"time"
 
+	"github.com/lgdzz/vingo-utils-v3/vingo"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/plain"
 )
@@ -19,11 +21,23 @@ import (
 type Producer struct {
 	config *Config
 	writer *kafka.Writer
+	once   sync.Once
 }
 
 // NewProducer 初始化生产者
 func NewProducer(config *Config) *Producer {
-	writer := &kafka.Writer{
+	producer := &Producer{
+		config: config,
+		writer: newWriter(config),
+	}
+	vingo.RegisterShutdown("kafka生产者:"+config.Topic, vingo.ShutdownProducer, func(ctx context.Context) error {
+		return producer.Close()
+	})
+	return producer
+}
+
+func newWriter(config *Config) *kafka.Writer {
+	return &kafka.Writer{
 		Addr:     kafka.TCP(config.Broker),
 		Topic:    config.Topic,
 		Balancer: &kafka.LeastBytes{},
@@ -37,11 +51,6 @@ func NewProducer(config *Config) *Producer {
 			},
 		},
 	}
-
-	return &Producer{
-		config: config,
-		writer: writer,
-	}
 }
 
 // Send 发送消息，支持 string/[]byte/struct/map/slice
@@ -71,10 +80,13 @@ func (p *Producer) Send(msg any) error {
 	)
 }
 
-// Close 关闭生产者，释放连接
+// Close 关闭生产者，等待缓冲中的消息发送完成后释放连接
 func (p *Producer) Close() error {
-	if p.writer != nil {
-		return p.writer.Close()
-	}
-	return nil
+	var err error
+	p.once.Do(func() {
+		if p.writer != nil {
+			err = p.writer.Close()
+		}
+	})
+	return err
 }
@@ -0,0 +1,440 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：指标统计，输出 Prometheus 文本格式
+//
+// 业务指标：
+//
+//	var orderCreated = metrics.NewCounter("order_created_total", "下单数", "channel")
+//	orderCreated.Inc("app")
+//
+// 连接池、队列长度等在采集时读取的指标通过 RegisterCollector 注册：
+//
+//	metrics.RegisterCollector(func(w *metrics.Writer) {
+//		w.Gauge("cache_items", "缓存条数", nil, float64(cache.Len()))
+//	})
+// *****************************************************************************
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefaultBuckets 默认耗时分布（秒）
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Labels 标签，输出时按名称排序
+type Labels map[string]string
+
+type metric interface {
+	describe() (name string, help string, kind string)
+	write(w *Writer)
+}
+
+type registry struct {
+	mu         sync.RWMutex
+	metrics    map[string]metric
+	collectors []func(w *Writer)
+}
+
+var defaultRegistry = &registry{metrics: map[string]metric{}}
+
+// register 同名指标已存在时返回已有的，类型或标签不一致时panic
+func register[T metric](m T, labelNames []string) T {
+	name, _, kind := m.describe()
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	if exists, ok := defaultRegistry.metrics[name]; ok {
+		_, _, existsKind := exists.describe()
+		old, same := exists.(T)
+		if !same || existsKind != kind || !sameLabels(exists, labelNames) {
+			panic(fmt.Sprintf("指标[%v]已注册为其他类型或标签", name))
+		}
+		return old
+	}
+	defaultRegistry.metrics[name] = m
+	return m
+}
+
+func sameLabels(m metric, labelNames []string) bool {
+	var names []string
+	switch v := m.(type) {
+	case *Counter:
+		names = v.vec.labelNames
+	case *Gauge:
+		names = v.vec.labelNames
+	case *Histogram:
+		names = v.labelNames
+	}
+	return strings.Join(names, ",") == strings.Join(labelNames, ",")
+}
+
+// RegisterCollector 注册采集时执行的方法
+func RegisterCollector(collect func(w *Writer)) {
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	defaultRegistry.collectors = append(defaultRegistry.collectors, collect)
+}
+
+// vec 按标签值保存的数值
+type vec struct {
+	name       string
+	help       string
+	labelNames []string
+	mu         sync.Mutex
+	values     map[string]*sample
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+func newVec(name string, help string, labelNames []string) vec {
+	checkName(name)
+	for _, label := range labelNames {
+		checkName(label)
+	}
+	return vec{name: name, help: help, labelNames: labelNames, values: map[string]*sample{}}
+}
+
+func (s *vec) get(labelValues []string) *sample {
+	if len(labelValues) != len(s.labelNames) {
+		panic(fmt.Sprintf("指标[%v]需要%d个标签值，实际%d个", s.name, len(s.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	item, ok := s.values[key]
+	if !ok {
+		item = &sample{labelValues: append([]string{}, labelValues...)}
+		s.values[key] = item
+	}
+	return item
+}
+
+func (s *vec) write(w *Writer, kind string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range sortedKeys(s.values) {
+		item := s.values[key]
+		w.add(s.name, s.help, kind, "", s.labels(item.labelValues), item.value)
+	}
+	if len(s.labelNames) == 0 && len(s.values) == 0 {
+		w.add(s.name, s.help, kind, "", nil, 0)
+	}
+}
+
+func (s *vec) labels(values []string) Labels {
+	if len(values) == 0 {
+		return nil
+	}
+	labels := make(Labels, len(values))
+	for i, name := range s.labelNames {
+		labels[name] = values[i]
+	}
+	return labels
+}
+
+// Counter 只增不减的计数
+type Counter struct {
+	vec vec
+}
+
+// NewCounter 新建计数器，labelNames 为标签名称，记录时按顺序传入标签值
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	return register(&Counter{vec: newVec(name, help, labelNames)}, labelNames)
+}
+
+func (s *Counter) Inc(labelValues ...string) {
+	s.Add(1, labelValues...)
+}
+
+func (s *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("计数器[%v]不能减少", s.vec.name))
+	}
+	s.vec.mu.Lock()
+	s.vec.get(labelValues).value += value
+	s.vec.mu.Unlock()
+}
+
+func (s *Counter) describe() (string, string, string) {
+	return s.vec.name, s.vec.help, typeCounter
+}
+
+func (s *Counter) write(w *Writer) {
+	s.vec.write(w, typeCounter)
+}
+
+// Gauge 可增可减的数值
+type Gauge struct {
+	vec vec
+}
+
+// NewGauge 新建数值指标
+func NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return register(&Gauge{vec: newVec(name, help, labelNames)}, labelNames)
+}
+
+func (s *Gauge) Set(value float64, labelValues ...string) {
+	s.vec.mu.Lock()
+	s.vec.get(labelValues).value = value
+	s.vec.mu.Unlock()
+}
+
+func (s *Gauge) Add(value float64, labelValues ...string) {
+	s.vec.mu.Lock()
+	s.vec.get(labelValues).value += value
+	s.vec.mu.Unlock()
+}
+
+func (s *Gauge) Inc(labelValues ...string) {
+	s.Add(1, labelValues...)
+}
+
+func (s *Gauge) Dec(labelValues ...string) {
+	s.Add(-1, labelValues...)
+}
+
+func (s *Gauge) describe() (string, string, string) {
+	return s.vec.name, s.vec.help, typeGauge
+}
+
+func (s *Gauge) write(w *Writer) {
+	s.vec.write(w, typeGauge)
+}
+
+// Histogram 分布统计，如接口耗时
+type Histogram struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	mu         sync.Mutex
+	values     map[string]*histogramSample
+}
+
+type histogramSample struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram 新建分布统计，buckets 为空时使用 DefaultBuckets
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	checkName(name)
+	for _, label := range labelNames {
+		checkName(label)
+	}
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return register(&Histogram{name: name, help: help, labelNames: labelNames, buckets: buckets, values: map[string]*histogramSample{}}, labelNames)
+}
+
+func (s *Histogram) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(s.labelNames) {
+		panic(fmt.Sprintf("指标[%v]需要%d个标签值，实际%d个", s.name, len(s.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.values[key]
+	if !ok {
+		item = &histogramSample{labelValues: append([]string{}, labelValues...), counts: make([]uint64, len(s.buckets))}
+		s.values[key] = item
+	}
+	for i, bound := range s.buckets {
+		if value <= bound {
+			item.counts[i]++
+		}
+	}
+	item.count++
+	item.sum += value
+}
+
+func (s *Histogram) describe() (string, string, string) {
+	return s.name, s.help, typeHistogram
+}
+
+func (s *Histogram) write(w *Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range sortedKeys(s.values) {
+		item := s.values[key]
+		labels := Labels{}
+		for i, name := range s.labelNames {
+			labels[name] = item.labelValues[i]
+		}
+		for i, bound := range s.buckets {
+			w.add(s.name, s.help, typeHistogram, "_bucket", withLabel(labels, "le", formatFloat(bound)), float64(item.counts[i]))
+		}
+		w.add(s.name, s.help, typeHistogram, "_bucket", withLabel(labels, "le", "+Inf"), float64(item.count))
+		w.add(s.name, s.help, typeHistogram, "_sum", labels, item.sum)
+		w.add(s.name, s.help, typeHistogram, "_count", labels, float64(item.count))
+	}
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func withLabel(labels Labels, name string, value string) Labels {
+	result := make(Labels, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[name] = value
+	return result
+}
+
+// Writer 采集结果，同名指标合并输出
+type Writer struct {
+	families map[string]*family
+}
+
+type family struct {
+	help    string
+	kind    string
+	samples []string
+}
+
+// Gauge 输出数值
+func (w *Writer) Gauge(name string, help string, labels Labels, value float64) {
+	w.add(name, help, typeGauge, "", labels, value)
+}
+
+// Counter 输出累计值，如连接池累计等待次数
+func (w *Writer) Counter(name string, help string, labels Labels, value float64) {
+	w.add(name, help, typeCounter, "", labels, value)
+}
+
+func (w *Writer) add(name string, help string, kind string, suffix string, labels Labels, value float64) {
+	f, ok := w.families[name]
+	if !ok {
+		f = &family{help: help, kind: kind}
+		w.families[name] = f
+	}
+	f.samples = append(f.samples, name+suffix+formatLabels(labels)+" "+formatFloat(value))
+}
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		items = append(items, name+`="`+escapeLabel(labels[name])+`"`)
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func checkName(name string) {
+	for i, r := range name {
+		if !(r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			panic(fmt.Sprintf("指标名称不合法：%v", name))
+		}
+	}
+}
+
+// Write 输出全部指标
+func Write(out io.Writer) error {
+	w := &Writer{families: map[string]*family{}}
+	defaultRegistry.mu.RLock()
+	metrics := make([]metric, 0, len(defaultRegistry.metrics))
+	for _, m := range defaultRegistry.metrics {
+		metrics = append(metrics, m)
+	}
+	collectors := append([]func(w *Writer){}, defaultRegistry.collectors...)
+	defaultRegistry.mu.RUnlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+	for _, collect := range collectors {
+		collectSafe(collect, w)
+	}
+
+	names := make([]string, 0, len(w.families))
+	for name := range w.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		f := w.families[name]
+		if f.help != "" {
+			b.WriteString("# HELP " + name + " " + helpEscaper.Replace(f.help) + "\n")
+		}
+		b.WriteString("# TYPE " + name + " " + f.kind + "\n")
+		for _, line := range f.samples {
+			b.WriteString(line + "\n")
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// collectSafe 单个采集方法异常不影响其他指标
+func collectSafe(collect func(w *Writer), w *Writer) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.add("metrics_collector_errors", "采集异常次数", typeGauge, "", Labels{"error": fmt.Sprintf("%v", r)}, 1)
+		}
+	}()
+	collect(w)
+}
+
+// Handler 输出指标的 http.Handler
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = Write(w)
+	})
+}
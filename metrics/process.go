@@ -0,0 +1,50 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：进程指标
+// *****************************************************************************
+
+package metrics
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func init() {
+	RegisterCollector(collectProcess)
+}
+
+func collectProcess(w *Writer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	w.Gauge("go_goroutines", "协程数", nil, float64(runtime.NumGoroutine()))
+	w.Gauge("go_memstats_heap_alloc_bytes", "堆内存使用", nil, float64(stats.HeapAlloc))
+	w.Gauge("go_memstats_heap_inuse_bytes", "堆内存占用", nil, float64(stats.HeapInuse))
+	w.Gauge("go_memstats_sys_bytes", "从系统申请的内存", nil, float64(stats.Sys))
+	w.Counter("go_gc_cycles_total", "GC次数", nil, float64(stats.NumGC))
+	w.Counter("go_gc_pause_seconds_total", "GC暂停累计时间", nil, float64(stats.PauseTotalNs)/1e9)
+
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return
+	}
+	if mem, err := p.MemoryInfo(); err == nil {
+		w.Gauge("process_resident_memory_bytes", "常驻内存", nil, float64(mem.RSS))
+		w.Gauge("process_virtual_memory_bytes", "虚拟内存", nil, float64(mem.VMS))
+	}
+	if times, err := p.Times(); err == nil {
+		w.Counter("process_cpu_seconds_total", "CPU累计时间", nil, times.User+times.System)
+	}
+	if threads, err := p.NumThreads(); err == nil {
+		w.Gauge("process_threads", "线程数", nil, float64(threads))
+	}
+	if fds, err := p.NumFDs(); err == nil {
+		w.Gauge("process_open_fds", "打开的文件描述符", nil, float64(fds))
+	}
+	if created, err := p.CreateTime(); err == nil {
+		w.Gauge("process_start_time_seconds", "进程启动时间", nil, float64(created)/1000)
+	}
+}
@@ -0,0 +1,306 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：OpenAPI 3 接口文档
+//
+// 使用带类型的路由注册方法声明请求和响应类型，处理方法与 vingo.RoutesGet/RoutesPost 相同：
+//
+//	openapi.Get[UserQuery, db.PageResult](g, "/user.list", user.List, openapi.Doc{Summary: "用户列表", Tags: []string{"用户"}})
+//	openapi.Post[UserInput, openapi.None](g, "/user.create", user.Create, openapi.Doc{Summary: "新增用户", Tags: []string{"用户"}})
+//
+// GET、DELETE 请求类型按 form 标签生成查询参数，其他方法生成 JSON 请求体；
+// 响应类型为 data 字段的内容，外层为统一的 {uuid, error, message, data, timestamp}。
+// 调试模式下路由服务注册 /openapi.json 和 /swagger，/swagger 页面的 Swagger UI 静态资源从 SwaggerAssets 加载，
+// 默认为公共 CDN（unpkg），无法访问外网时指向内部镜像或自行部署的 swagger-ui-dist
+// *****************************************************************************
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+// None 无请求参数或无响应数据
+type None struct{}
+
+type Doc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+}
+
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+type operation struct {
+	method   string
+	path     string
+	doc      Doc
+	request  reflect.Type
+	response reflect.Type
+}
+
+var (
+	operationMu sync.RWMutex
+	operations  []operation
+)
+
+func typeOf[T any]() reflect.Type {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t == reflect.TypeOf(None{}) {
+		return nil
+	}
+	return t
+}
+
+func register[Req any, Resp any](method string, g *gin.RouterGroup, path string, doc Doc) {
+	operationMu.Lock()
+	defer operationMu.Unlock()
+	operations = append(operations, operation{
+		method:   method,
+		path:     joinPath(g.BasePath(), path),
+		doc:      doc,
+		request:  typeOf[Req](),
+		response: typeOf[Resp](),
+	})
+}
+
+func joinPath(base string, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Get 注册get路由并记录接口文档
+func Get[Req any, Resp any](g *gin.RouterGroup, path string, handler func(*vingo.Context), doc Doc) {
+	vingo.RoutesGet(g, path, handler)
+	register[Req, Resp](http.MethodGet, g, path, doc)
+}
+
+// Post 注册post路由并记录接口文档，middles 在 handler 之前执行
+func Post[Req any, Resp any](g *gin.RouterGroup, path string, handler func(*vingo.Context), doc Doc, middles ...gin.HandlerFunc) {
+	vingo.RoutesPost(g, path, handler, middles...)
+	register[Req, Resp](http.MethodPost, g, path, doc)
+}
+
+// Put 注册put路由并记录接口文档
+func Put[Req any, Resp any](g *gin.RouterGroup, path string, handler func(*vingo.Context), doc Doc) {
+	vingo.RoutesPut(g, path, handler)
+	register[Req, Resp](http.MethodPut, g, path, doc)
+}
+
+// Patch 注册patch路由并记录接口文档
+func Patch[Req any, Resp any](g *gin.RouterGroup, path string, handler func(*vingo.Context), doc Doc) {
+	vingo.RoutesPatch(g, path, handler)
+	register[Req, Resp](http.MethodPatch, g, path, doc)
+}
+
+// Delete 注册delete路由并记录接口文档
+func Delete[Req any, Resp any](g *gin.RouterGroup, path string, handler func(*vingo.Context), doc Doc) {
+	vingo.RoutesDelete(g, path, handler)
+	register[Req, Resp](http.MethodDelete, g, path, doc)
+}
+
+// Document 根据已注册的路由生成文档
+func Document(info Info) map[string]any {
+	operationMu.RLock()
+	items := append([]operation{}, operations...)
+	operationMu.RUnlock()
+
+	b := newBuilder()
+	paths := map[string]map[string]any{}
+	tags := map[string]bool{}
+	for _, item := range items {
+		path, params := pathParams(item.path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		op := map[string]any{
+			"operationId": strings.ToLower(item.method) + ":" + item.path,
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "error：0-成功|1-失败，失败时 message 为错误信息",
+					"content":     map[string]any{"application/json": map[string]any{"schema": envelope(b, item.response)}},
+				},
+			},
+		}
+		if item.doc.Summary != "" {
+			op["summary"] = item.doc.Summary
+		}
+		if item.doc.Description != "" {
+			op["description"] = item.doc.Description
+		}
+		if len(item.doc.Tags) > 0 {
+			op["tags"] = item.doc.Tags
+			for _, tag := range item.doc.Tags {
+				tags[tag] = true
+			}
+		}
+		if item.doc.Deprecated {
+			op["deprecated"] = true
+		}
+		if item.request != nil {
+			if item.method == http.MethodGet || item.method == http.MethodDelete {
+				params = append(params, b.queryParams(item.request)...)
+			} else {
+				op["requestBody"] = map[string]any{
+					"required": true,
+					"content":  map[string]any{"application/json": map[string]any{"schema": b.schema(item.request)}},
+				}
+			}
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		paths[path][strings.ToLower(item.method)] = op
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for tag := range tags {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	tagList := make([]map[string]string, 0, len(tagNames))
+	for _, tag := range tagNames {
+		tagList = append(tagList, map[string]string{"name": tag})
+	}
+
+	if info.Title == "" {
+		info.Title = "API"
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info":    info,
+		"paths":   paths,
+		"tags":    tagList,
+	}
+	if len(b.schemas) > 0 {
+		doc["components"] = map[string]any{"schemas": b.schemas}
+	}
+	return doc
+}
+
+// MarshalJSON 字段名使用小写
+func (s Info) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"title": s.Title, "version": s.Version, "description": s.Description})
+}
+
+// envelope 统一响应结构
+func envelope(b *builder, response reflect.Type) *Schema {
+	data := &Schema{Nullable: true}
+	if response != nil {
+		data = b.schema(response)
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"uuid":      {Type: "string", Description: "请求标识"},
+			"error":     {Type: "integer", Description: "0-无错误|1-有错误"},
+			"message":   {Type: "string"},
+			"data":      data,
+			"timestamp": {Type: "integer", Format: "int64"},
+		},
+	}
+}
+
+// pathParams gin路由参数 :id、*path 转换为 {id}、{path}
+func pathParams(route string) (string, []map[string]any) {
+	var params []map[string]any
+	parts := strings.Split(route, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			name := part[1:]
+			parts[i] = "{" + name + "}"
+			params = append(params, map[string]any{"name": name, "in": "path", "required": true, "schema": &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// queryParams 查询参数，取 form 标签；无 form 标签的结构体字段展开，其他字段忽略
+func (s *builder) queryParams(t reflect.Type) []map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []map[string]any
+	comments := fieldComments(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if name == "" {
+			if ft.Kind() == reflect.Struct && s.format(ft) == nil && !reflect.PointerTo(ft).Implements(textUnmarshaler) {
+				params = append(params, s.queryParams(ft)...)
+			}
+			continue
+		}
+		schema := s.schema(field.Type)
+		required := applyRules(schema, field)
+		param := map[string]any{"name": name, "in": "query", "schema": schema}
+		if required {
+			param["required"] = true
+		}
+		if comment := comments[field.Name]; comment != "" {
+			param["description"] = comment
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// SwaggerAssets swagger-ui-dist 静态资源地址，需包含 swagger-ui.css 和 swagger-ui-bundle.js
+var SwaggerAssets = "https://unpkg.com/swagger-ui-dist@5"
+
+// Mount 注册 /openapi.json 和 /swagger，Swagger UI 静态资源从 SwaggerAssets 加载
+func Mount(r gin.IRoutes, info Info) {
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, Document(info))
+	})
+	r.GET("/swagger", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(strings.NewReplacer("{{title}}", info.Title, "{{assets}}", strings.TrimSuffix(SwaggerAssets, "/")).Replace(swaggerHtml)))
+	})
+}
+
+const swaggerHtml = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{title}}</title>
+<link rel="stylesheet" href="{{assets}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{assets}}/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui", deepLinking: true});
+</script>
+</body>
+</html>`
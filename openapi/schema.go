@@ -0,0 +1,388 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：根据Go类型生成 OpenAPI Schema
+//
+// 字段名取 json 标签（查询参数取 form 标签），说明取字段的行尾注释，
+// binding/validate 标签转换为 required、长度、范围、枚举等约束，ctype/moment 类型按其 JSON 格式输出
+// *****************************************************************************
+
+package openapi
+
+import (
+	"encoding"
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ddl "github.com/lgdzz/vingo-utils-v3/db/create"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// builder 生成一份文档过程中的结构体定义
+type builder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newBuilder() *builder {
+	return &builder{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// format 特殊类型按 JSON 输出格式描述，返回nil时按类型结构生成
+func (s *builder) format(t reflect.Type) *Schema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	name := t.Name()
+	switch t.PkgPath() {
+	case "github.com/lgdzz/vingo-utils-v3/moment":
+		switch name {
+		case "LocalTime":
+			return &Schema{Type: "string", Format: "date-time", Description: "yyyy-MM-dd HH:mm:ss"}
+		case "DateText":
+			return &Schema{Type: "string", Format: "date"}
+		case "DateTextRange":
+			return &Schema{Type: "string", Description: "开始,结束"}
+		}
+	case "github.com/lgdzz/vingo-utils-v3/ctype":
+		switch {
+		case name == "Money":
+			return &Schema{Type: "number", Format: "money"}
+		case name == "Moneys":
+			return &Schema{Type: "array", Items: &Schema{Type: "number", Format: "money"}}
+		case name == "Ratio":
+			return &Schema{Type: "number", Format: "ratio", Description: "百分比"}
+		case name == "Bool":
+			return &Schema{Type: "boolean"}
+		case name == "IdCard":
+			return &Schema{Type: "string", Format: "id-card"}
+		case name == "Phone":
+			return &Schema{Type: "string", Format: "phone"}
+		case name == "IP":
+			return &Schema{Type: "string", Format: "ip"}
+		case name == "Ciphertext":
+			return &Schema{Type: "string", Format: "ciphertext"}
+		case name == "Password":
+			return &Schema{Type: "string", Format: "password"}
+		case strings.HasPrefix(name, "Path["):
+			return &Schema{Type: "string", Format: "path", Description: "逗号分隔的路径"}
+		case strings.HasPrefix(name, "Strings["), strings.HasPrefix(name, "Jsons["):
+			return &Schema{Type: "array", Items: s.schema(t.Elem())}
+		}
+	case "github.com/lgdzz/vingo-utils-v3/db":
+		if name == "TextSlice" {
+			return &Schema{Type: "string", Description: "逗号分隔的多个值"}
+		}
+	}
+	return nil
+}
+
+// schema 类型对应的 Schema，结构体放到 components 中引用
+func (s *builder) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	if f := s.format(t); f != nil {
+		f.Nullable = nullable
+		return f
+	}
+	var result *Schema
+	switch t.Kind() {
+	case reflect.Bool:
+		result = &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		result = &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		result = &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		result = &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		result = &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		result = &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			result = &Schema{Type: "string", Format: "byte"}
+		} else {
+			result = &Schema{Type: "array", Items: s.schema(t.Elem())}
+		}
+	case reflect.Map:
+		result = &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			result = s.object(t)
+		} else {
+			result = &Schema{Ref: "#/components/schemas/" + s.component(t)}
+		}
+	default:
+		result = &Schema{}
+	}
+	result.Nullable = result.Nullable || (nullable && result.Ref == "")
+	return result
+}
+
+// component 注册结构体定义，返回名称
+func (s *builder) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := typeName(t)
+	for i := 2; ; i++ {
+		if _, exists := s.schemas[name]; !exists {
+			break
+		}
+		name = typeName(t) + strconv.Itoa(i)
+	}
+	s.names[t] = name
+	// 先占位，递归类型引用自身时不再重复生成
+	s.schemas[name] = &Schema{}
+	*s.schemas[name] = *s.object(t)
+	return name
+}
+
+var typeArgPattern = regexp.MustCompile(`[\w./-]+/`)
+
+// typeName 包名.类型名，泛型参数去掉包路径
+func typeName(t reflect.Type) string {
+	name := typeArgPattern.ReplaceAllString(t.Name(), "")
+	name = path.Base(t.PkgPath()) + "." + name
+	return strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "", " ", "").Replace(name)
+}
+
+// object 结构体字段，匿名嵌入的结构体字段展开
+func (s *builder) object(t reflect.Type) *Schema {
+	result := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	comments := fieldComments(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && s.format(ft) == nil {
+				embedded := s.object(ft)
+				for key, value := range embedded.Properties {
+					result.Properties[key] = value
+				}
+				result.Required = append(result.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := s.schema(field.Type)
+		required := applyRules(property, field)
+		result.Properties[name] = describe(property, comments[field.Name])
+		if required {
+			result.Required = append(result.Required, name)
+		}
+	}
+	return result
+}
+
+// describe 添加说明，引用类型通过 allOf 附加说明
+func describe(schema *Schema, description string) *Schema {
+	if description == "" {
+		return schema
+	}
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Description: description, Nullable: schema.Nullable}
+	}
+	if schema.Description != "" {
+		description += "（" + schema.Description + "）"
+	}
+	schema.Description = description
+	return schema
+}
+
+// rules binding 和 validate 标签中的规则，dive 之后的规则作用于元素，不处理
+func rules(field reflect.StructField) []string {
+	var result []string
+	for _, key := range []string{"binding", "validate"} {
+		for _, rule := range strings.Split(field.Tag.Get(key), ",") {
+			rule = strings.TrimSpace(rule)
+			if rule == "dive" {
+				break
+			}
+			if rule != "" {
+				result = append(result, rule)
+			}
+		}
+	}
+	return result
+}
+
+// applyRules 按校验规则添加约束，返回是否必填
+func applyRules(schema *Schema, field reflect.StructField) (required bool) {
+	for _, rule := range rules(field) {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "len", "gte", "lte", "gt", "lt":
+			value, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			applyRange(schema, name, value)
+		case "oneof":
+			for _, item := range strings.Fields(param) {
+				if schema.Type == "integer" || schema.Type == "number" {
+					if number, err := strconv.ParseFloat(item, 64); err == nil {
+						schema.Enum = append(schema.Enum, number)
+						continue
+					}
+				}
+				schema.Enum = append(schema.Enum, item)
+			}
+		case "email", "uuid", "ip", "ipv4", "ipv6", "uri", "hostname":
+			schema.Format = name
+		case "url":
+			schema.Format = "uri"
+		case "datetime":
+			schema.Format = "date-time"
+			schema.Description = strings.TrimSpace(schema.Description + " " + param)
+		}
+	}
+	return required
+}
+
+func applyRange(schema *Schema, rule string, value float64) {
+	size := int(value)
+	switch schema.Type {
+	case "string":
+		switch rule {
+		case "min", "gte":
+			schema.MinLength = &size
+		case "max", "lte":
+			schema.MaxLength = &size
+		case "len":
+			schema.MinLength, schema.MaxLength = &size, &size
+		}
+	case "array":
+		switch rule {
+		case "min", "gte":
+			schema.MinItems = &size
+		case "max", "lte":
+			schema.MaxItems = &size
+		case "len":
+			schema.MinItems, schema.MaxItems = &size, &size
+		}
+	case "integer", "number":
+		switch rule {
+		case "min", "gte":
+			schema.Minimum = &value
+		case "max", "lte":
+			schema.Maximum = &value
+		case "gt":
+			schema.Minimum, schema.ExclusiveMinimum = &value, true
+		case "lt":
+			schema.Maximum, schema.ExclusiveMaximum = &value, true
+		case "len":
+			schema.Minimum, schema.Maximum = &value, &value
+		}
+	}
+}
+
+var (
+	commentMu    sync.Mutex
+	commentCache = map[reflect.Type]map[string]string{}
+)
+
+// fieldComments 从源码中读取字段注释，仅能读取当前项目（主模块）内的类型
+func fieldComments(t reflect.Type) map[string]string {
+	commentMu.Lock()
+	defer commentMu.Unlock()
+	if comments, ok := commentCache[t]; ok {
+		return comments
+	}
+	comments := map[string]string{}
+	commentCache[t] = comments
+
+	dir := sourceDir(t.PkgPath())
+	if dir == "" {
+		return comments
+	}
+	structName, _, _ := strings.Cut(t.Name(), "[")
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, filepath.Base(file)); err != nil || !match {
+			continue
+		}
+		result, err := ddl.ParseStructFieldComments(file, structName)
+		if err != nil {
+			continue
+		}
+		for key, value := range result {
+			comments[key] = value
+		}
+	}
+	return comments
+}
+
+// SourceRoot 项目源码根目录（go.mod 所在目录），默认当前工作目录
+var SourceRoot = "."
+
+// sourceDir 包路径对应的源码目录
+func sourceDir(pkgPath string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path == "" {
+		return ""
+	}
+	module := info.Main.Path
+	if pkgPath != module && !strings.HasPrefix(pkgPath, module+"/") {
+		return ""
+	}
+	dir := filepath.Join(SourceRoot, strings.TrimPrefix(pkgPath, module))
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
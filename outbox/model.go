@@ -0,0 +1,101 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：事务发件箱-消息表
+//
+// 业务数据与待发布消息在同一个事务内写入，提交后由Relay异步投递，
+// 避免“提交成功但消息丢失”的问题。投递语义为至少一次，消费端需自行保证幂等。
+//
+// 用法：
+//
+//	tx := api.Begin()
+//	defer api.AutoCommit(tx)
+//	tx.Create(&order)
+//	outbox.Push(tx, "order", OrderCreated{Id: order.Id})          // 投递到 queue.Redis
+//	outbox.PushKafka(tx, "order-event", OrderCreated{Id: order.Id}) // 投递到 kafka
+// *****************************************************************************
+
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lgdzz/vingo-utils-v3/moment"
+	"gorm.io/gorm"
+)
+
+const (
+	TargetRedis = "redis"
+	TargetKafka = "kafka"
+
+	StatusPending = 0 // 待投递
+	StatusSent    = 1 // 已投递
+	StatusFailed  = 2 // 投递失败（超过最大重试次数）
+)
+
+// TableName 发件箱表名，如需自定义请在迁移前修改
+var TableName = "vingo_outbox"
+
+type Message struct {
+	Id        int64             `gorm:"primaryKey;column:id" json:"id"`
+	Target    string            `gorm:"column:target;size:20" json:"target"`                                    // 投递目标：redis|kafka
+	Topic     string            `gorm:"column:topic;size:100" json:"topic"`                                     // 主题
+	Payload   string            `gorm:"column:payload;type:text" json:"payload"`                                // 消息内容
+	Status    int               `gorm:"column:status;index:idx_vingo_outbox_pending,priority:1" json:"status"`  // 状态：0-待投递 1-已投递 2-投递失败
+	Attempts  int               `gorm:"column:attempts" json:"attempts"`                                        // 已尝试次数
+	NextAt    *moment.LocalTime `gorm:"column:next_at;index:idx_vingo_outbox_pending,priority:2" json:"nextAt"` // 下次投递时间
+	LastError string            `gorm:"column:last_error;type:text" json:"lastError"`                           // 最近一次错误
+	CreatedAt *moment.LocalTime `gorm:"column:created_at" json:"createdAt"`                                     // 创建时间
+	SentAt    *moment.LocalTime `gorm:"column:sent_at;index:idx_vingo_outbox_sent" json:"sentAt"`               // 投递时间
+}
+
+func (s *Message) TableName() string {
+	return TableName
+}
+
+// Write 在事务内写入一条待投递消息
+// target-投递目标：redis|kafka
+// value-消息内容，可选类型[string|[]byte|struct|map|slice]
+func Write(tx *gorm.DB, target string, topic string, value any) *Message {
+	if tx == nil {
+		panic("outbox.Write 事务对象不能为空")
+	}
+	message := &Message{
+		Target:    target,
+		Topic:     topic,
+		Payload:   toPayload(value),
+		Status:    StatusPending,
+		NextAt:    moment.NowLocalTime(),
+		CreatedAt: moment.NowLocalTime(),
+	}
+	if err := tx.Create(message).Error; err != nil {
+		panic(err.Error())
+	}
+	return message
+}
+
+// Push 在事务内写入一条 queue.Redis 消息
+func Push(tx *gorm.DB, topic string, value any) *Message {
+	return Write(tx, TargetRedis, topic, value)
+}
+
+// PushKafka 在事务内写入一条 kafka 消息
+func PushKafka(tx *gorm.DB, topic string, value any) *Message {
+	return Write(tx, TargetKafka, topic, value)
+}
+
+func toPayload(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("outbox 消息序列化失败: %v", err))
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,59 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：事务发件箱-投递器
+// *****************************************************************************
+
+package outbox
+
+import (
+	"fmt"
+
+	"github.com/lgdzz/vingo-utils-v3/kafka"
+	"github.com/lgdzz/vingo-utils-v3/queue"
+)
+
+// Publisher 消息投递接口，自定义投递目标需实现此接口
+type Publisher interface {
+	Publish(topic string, payload string) error
+}
+
+// RedisPublisher 投递到 redis 消息队列
+type RedisPublisher struct {
+	Queue *queue.Queue // 为空时使用 queue.Redis
+}
+
+func (s *RedisPublisher) Publish(topic string, payload string) (err error) {
+	q := s.Queue
+	if q == nil {
+		q = &queue.Redis
+	}
+	if q.Config.RedisApi == nil {
+		return fmt.Errorf("redis队列未初始化")
+	}
+	// queue.Push 出错时会panic，这里转换为error交由重试处理
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	q.Push(topic, payload)
+	return nil
+}
+
+// KafkaPublisher 投递到 kafka，kafka.Producer 与主题绑定，按主题选择生产者
+type KafkaPublisher struct {
+	Producers map[string]*kafka.Producer // key=主题
+	Default   *kafka.Producer            // 未匹配到主题时使用
+}
+
+func (s *KafkaPublisher) Publish(topic string, payload string) error {
+	producer, ok := s.Producers[topic]
+	if !ok || producer == nil {
+		producer = s.Default
+	}
+	if producer == nil {
+		return fmt.Errorf("kafka主题[%v]未配置生产者", topic)
+	}
+	return producer.Send(payload)
+}
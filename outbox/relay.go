@@ -0,0 +1,256 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：事务发件箱-后台投递
+// *****************************************************************************
+
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/duke-git/lancet/v2/pointer"
+	"github.com/lgdzz/vingo-utils-v3/moment"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Config struct {
+	Db           *gorm.DB             // 发件箱所在数据库
+	Publishers   map[string]Publisher // 投递器，key=投递目标，默认 redis=RedisPublisher
+	Debug        *bool                // 调试模式，为true时日志在控制台输出，否则记录到日志文件，默认为true
+	Interval     time.Duration        // 轮询间隔，默认1秒
+	BatchSize    int                  // 每批投递数量，默认100
+	MaxAttempts  int                  // 最大尝试次数，超过后标记为投递失败，默认10
+	Backoff      time.Duration        // 首次重试等待时间，之后按指数递增，默认5秒
+	MaxBackoff   time.Duration        // 最大重试等待时间，默认10分钟
+	Retention    time.Duration        // 已投递消息保留时长，默认7天
+	CleanupEvery time.Duration        // 清理间隔，默认1小时
+	SkipLocked   *bool                // 是否使用 FOR UPDATE SKIP LOCKED 加锁（多实例部署时避免重复投递），默认true；MySQL 5.7 请设置为false
+}
+
+type Relay struct {
+	Config Config
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRelay 新建发件箱投递器
+func NewRelay(config Config) *Relay {
+	if config.Db == nil {
+		panic("outbox.Config.Db 不能为空")
+	}
+	if config.Publishers == nil {
+		config.Publishers = map[string]Publisher{}
+	}
+	if _, ok := config.Publishers[TargetRedis]; !ok {
+		config.Publishers[TargetRedis] = &RedisPublisher{}
+	}
+	if config.Debug == nil {
+		config.Debug = pointer.Of(true)
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 5 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 10 * time.Minute
+	}
+	if config.Retention <= 0 {
+		config.Retention = 7 * 24 * time.Hour
+	}
+	if config.CleanupEvery <= 0 {
+		config.CleanupEvery = time.Hour
+	}
+	if config.SkipLocked == nil {
+		config.SkipLocked = pointer.Of(true)
+	}
+	return &Relay{Config: config}
+}
+
+// Migrate 创建发件箱表（已存在则跳过）
+func (s *Relay) Migrate() {
+	if s.Config.Db.Migrator().HasTable(&Message{}) {
+		return
+	}
+	if err := s.Config.Db.Migrator().CreateTable(&Message{}); err != nil {
+		panic(fmt.Sprintf("创建发件箱表失败: %v", err))
+	}
+}
+
+// Start 启动后台投递和清理
+func (s *Relay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(2)
+	go s.loop(ctx, s.Config.Interval, func() {
+		// 本批次满额时说明还有积压，立即继续
+		for {
+			if s.RunOnce() < s.Config.BatchSize || ctx.Err() != nil {
+				return
+			}
+		}
+	})
+	go s.loop(ctx, s.Config.CleanupEvery, func() {
+		s.Cleanup()
+	})
+
+	// 停机时在关闭生产者和数据库前停止投递
+	vingo.RegisterShutdown("事务发件箱", vingo.ShutdownApp, func(ctx context.Context) error {
+		s.Stop()
+		return nil
+	})
+}
+
+// Stop 停止投递，等待当前批次完成
+func (s *Relay) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Relay) loop(ctx context.Context, interval time.Duration, handle func()) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.guard(handle)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// guard 数据库异常会panic，这里捕获后记录日志，等待下一轮
+func (s *Relay) guard(handle func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			s.log(fmt.Sprintf("[发件箱]投递异常：%v", err))
+		}
+	}()
+	handle()
+}
+
+// RunOnce 投递一批到期的消息，返回本批次处理数量
+func (s *Relay) RunOnce() int {
+	var count int
+	tx := s.Config.Db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query := tx.Where("status = ? AND next_at <= ?", StatusPending, moment.NowLocalTime())
+	if *s.Config.SkipLocked {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+	var list []Message
+	query.Order("id asc").Limit(s.Config.BatchSize).Find(&list)
+
+	for _, message := range list {
+		s.deliver(tx, message)
+		count++
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		panic(err.Error())
+	}
+	return count
+}
+
+func (s *Relay) deliver(tx *gorm.DB, message Message) {
+	var err error
+	if publisher, ok := s.Config.Publishers[message.Target]; ok {
+		err = publisher.Publish(message.Topic, message.Payload)
+	} else {
+		err = fmt.Errorf("投递目标[%v]未配置", message.Target)
+	}
+
+	if err == nil {
+		tx.Model(&Message{}).Where("id = ?", message.Id).Updates(map[string]any{
+			"status":   StatusSent,
+			"attempts": message.Attempts + 1,
+			"sent_at":  moment.NowLocalTime(),
+		})
+		return
+	}
+
+	attempts := message.Attempts + 1
+	values := map[string]any{
+		"attempts":   attempts,
+		"last_error": err.Error(),
+	}
+	if attempts >= s.Config.MaxAttempts {
+		values["status"] = StatusFailed
+		s.log(fmt.Sprintf("[发件箱]消息投递失败，已达最大重试次数，Id：%v，Topic：%v，Error：%v", message.Id, message.Topic, err))
+	} else {
+		values["next_at"] = moment.ToLocalTime(time.Now().Add(s.backoff(attempts)))
+		s.log(fmt.Sprintf("[发件箱]消息投递失败，等待重试，Id：%v，Topic：%v，Error：%v", message.Id, message.Topic, err))
+	}
+	tx.Model(&Message{}).Where("id = ?", message.Id).Updates(values)
+}
+
+// backoff 指数退避，第n次失败等待 Backoff*2^(n-1)
+func (s *Relay) backoff(attempts int) time.Duration {
+	wait := s.Config.Backoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= s.Config.MaxBackoff {
+			return s.Config.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// Cleanup 清理超过保留时长的已投递消息
+func (s *Relay) Cleanup() int64 {
+	before := moment.ToLocalTime(time.Now().Add(-s.Config.Retention))
+	result := s.Config.Db.Where("status = ? AND sent_at < ?", StatusSent, before).Delete(&Message{})
+	if result.Error != nil {
+		panic(result.Error.Error())
+	}
+	return result.RowsAffected
+}
+
+// Retry 将投递失败的消息重置为待投递
+func (s *Relay) Retry(id ...int64) int64 {
+	query := s.Config.Db.Model(&Message{}).Where("status = ?", StatusFailed)
+	if len(id) > 0 {
+		query = query.Where("id IN ?", id)
+	}
+	result := query.Updates(map[string]any{
+		"status":   StatusPending,
+		"attempts": 0,
+		"next_at":  moment.NowLocalTime(),
+	})
+	if result.Error != nil {
+		panic(result.Error.Error())
+	}
+	return result.RowsAffected
+}
+
+func (s *Relay) log(message string) {
+	if *s.Config.Debug {
+		fmt.Println(message)
+	} else {
+		vingo.LogError(message)
+	}
+}
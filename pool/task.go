@@ -10,11 +10,27 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/duke-git/lancet/v2/slice"
+	"github.com/lgdzz/vingo-utils-v3/metrics"
 	"github.com/lgdzz/vingo-utils-v3/vingo"
 )
 
+// activeWorkers 所有协程池中正在执行任务的协程数
+var activeWorkers atomic.Int64
+
+func init() {
+	metrics.RegisterCollector(func(w *metrics.Writer) {
+		w.Gauge("goroutine_pool_active_workers", "协程池中正在执行任务的协程数", nil, float64(activeWorkers.Load()))
+	})
+}
+
+// ActiveWorkers 所有协程池中正在执行任务的协程数
+func ActiveWorkers() int64 {
+	return activeWorkers.Load()
+}
+
 // TaskFunc 支持返回结果和错误的任务函数签名
 type TaskFunc func(ctx context.Context) Result
 
@@ -60,7 +76,9 @@ func (s *GoroutinePool) Run() {
 					if !ok {
 						return
 					}
+					activeWorkers.Add(1)
 					res := task(s.ctx)
+					activeWorkers.Add(-1)
 
 					s.mu.Lock()
 					s.results = append(s.results, res)
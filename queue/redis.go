@@ -1,12 +1,16 @@
 package queue
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/duke-git/lancet/v2/pointer"
 	"github.com/go-redis/redis"
+	"github.com/lgdzz/vingo-utils-v3/metrics"
 	vReids "github.com/lgdzz/vingo-utils-v3/redis"
 	"github.com/lgdzz/vingo-utils-v3/vingo"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -23,6 +27,11 @@ type Config struct {
 
 type Queue struct {
 	Config Config
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup // 运行中的监听器
+	topics   sync.Map       // 推送或监听过的主题，用于统计队列长度
 }
 
 // InitRedisQueue 初始化服务（只需要执行1次）
@@ -60,6 +69,79 @@ func InitRedisQueue(config Config) {
 	} else {
 		Redis.Config.Handle = &Handle{}
 	}
+
+	Redis.stop = make(chan struct{})
+	vingo.RegisterShutdown("消息队列", vingo.ShutdownConsumer, Redis.Stop)
+	metrics.RegisterCollector(Redis.collectMetrics)
+}
+
+// collectMetrics 各主题待消费和延迟中的消息数
+func (s *Queue) collectMetrics(w *metrics.Writer) {
+	if s.Config.RedisApi == nil {
+		return
+	}
+	s.topics.Range(func(key, _ any) bool {
+		topic := key.(string)
+		labels := metrics.Labels{"topic": topic}
+		if n, err := s.Config.RedisApi.Client.LLen(s.getTopic(topic)).Result(); err == nil {
+			w.Gauge("queue_depth", "待消费的消息数", labels, float64(n))
+		}
+		if n, err := s.Config.RedisApi.Client.ZCard(s.getDelayTopic(topic)).Result(); err == nil {
+			w.Gauge("queue_delayed", "延迟中的消息数", labels, float64(n))
+		}
+		return true
+	})
+}
+
+// Stop 停止所有监听器，正在消费的消息处理完成后返回，ctx 超时时不再等待
+func (s *Queue) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+		}
+	})
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待消息处理超时：%w", ctx.Err())
+	}
+}
+
+// stopped 是否已停止
+func (s *Queue) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep 等待指定时间，停止时立即返回false
+func (s *Queue) sleep(d time.Duration) bool {
+	select {
+	case <-s.stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// guard 执行监听器，异常时返回false
+func (s *Queue) guard(run func()) (ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			ok = false
+		}
+	}()
+	run()
+	return true
 }
 
 // 将消息转换为字符串类型
@@ -87,6 +169,7 @@ func (s *Queue) getDelayTopic(topic string) string {
 // topic-消息队列主题
 // value-消息内容，可选类型[struct|string]
 func (s *Queue) Push(topic string, value any) bool {
+	s.topics.Store(topic, struct{}{})
 	r, err := s.Config.RedisApi.Client.RPush(s.getTopic(topic), s.toString(value)).Result()
 	if err != nil {
 		panic(err.Error())
@@ -99,6 +182,7 @@ func (s *Queue) Push(topic string, value any) bool {
 // value-消息内容，可选类型[struct|string]
 // delayed-延迟时间，单位：秒，如：60秒后执行，则传入60
 func (s *Queue) PushDelay(topic string, value any, delayed int64) bool {
+	s.topics.Store(topic, struct{}{})
 	var nowTime = time.Now()
 	var score = float64(nowTime.Add(time.Duration(delayed) * time.Second).Unix())
 	r, err := s.Config.RedisApi.Client.ZAdd(s.getDelayTopic(topic), redis.Z{Member: s.toString(value), Score: score}).Result()
@@ -110,32 +194,38 @@ func (s *Queue) PushDelay(topic string, value any, delayed int64) bool {
 
 // StartMonitor 开始监听队列信息
 func (s *Queue) StartMonitor(topic string, methods any) {
+	s.topics.Store(topic, struct{}{})
+	s.wg.Add(2)
 	go s.monitorGuard(topic, s.Config.Handle, methods)
 	go s.monitorGuardDelay(topic)
 }
 
 // 队列监听守卫
 func (s *Queue) monitorGuard(topic string, handler Handler, methods any) {
-	defer func() {
-		if err := recover(); err != nil {
-			// 等待3秒后重启监听器
-			time.Sleep(time.Second * time.Duration(*s.Config.AutoBootTime))
-			if *s.Config.Debug {
-				fmt.Println(fmt.Sprintf("[消息队列]监听器异常，进行重启."))
-			} else {
-				vingo.LogError(fmt.Sprintf("[消息队列]监听器异常，进行重启."))
-			}
-			s.monitorGuard(topic, handler, methods)
+	defer s.wg.Done()
+	for !s.guard(func() { s.monitor(topic, handler, methods) }) {
+		// 等待3秒后重启监听器
+		if !s.sleep(time.Second * time.Duration(*s.Config.AutoBootTime)) {
+			return
 		}
-	}()
-	s.monitor(topic, handler, methods)
+		if *s.Config.Debug {
+			fmt.Println(fmt.Sprintf("[消息队列]监听器异常，进行重启."))
+		} else {
+			vingo.LogError(fmt.Sprintf("[消息队列]监听器异常，进行重启."))
+		}
+	}
 }
 
-// 队列监听
+// 队列监听，停止后处理完当前消息再退出
 func (s *Queue) monitor(topic string, handler Handler, methods any) {
 	topicQueue := s.getTopic(topic)
-	for {
-		r, err := s.Config.RedisApi.Client.BLPop(0, topicQueue).Result()
+	// 阻塞读取设置超时，以便及时响应停止
+	timeout := time.Second * time.Duration(*s.Config.SortedSetRestTime)
+	for !s.stopped() {
+		r, err := s.Config.RedisApi.Client.BLPop(timeout, topicQueue).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
 		if err != nil {
 			panic(err.Error())
 		}
@@ -163,30 +253,30 @@ func (s *Queue) monitor(topic string, handler Handler, methods any) {
 // Deprecated: This function is no longer recommended for use.
 // Suggested: 集成到StartMonitor中一起开启
 func (s *Queue) StartMonitorDelay(topic string) {
+	s.wg.Add(1)
 	go s.monitorGuardDelay(topic)
 }
 
 // 队列监听守卫(延迟)
 func (s *Queue) monitorGuardDelay(topic string) {
-	defer func() {
-		if err := recover(); err != nil {
-			// 等待3秒后重启监听器
-			time.Sleep(time.Second * time.Duration(*s.Config.AutoBootTime))
-			if *s.Config.Debug {
-				fmt.Println(fmt.Sprintf("[消息队列]监听器delay异常，进行重启."))
-			} else {
-				vingo.LogError(fmt.Sprintf("[消息队列]监听器delay异常，进行重启."))
-			}
-			s.monitorGuardDelay(topic)
+	defer s.wg.Done()
+	for !s.guard(func() { s.monitorDelay(topic) }) {
+		// 等待3秒后重启监听器
+		if !s.sleep(time.Second * time.Duration(*s.Config.AutoBootTime)) {
+			return
 		}
-	}()
-	s.monitorDelay(topic)
+		if *s.Config.Debug {
+			fmt.Println(fmt.Sprintf("[消息队列]监听器delay异常，进行重启."))
+		} else {
+			vingo.LogError(fmt.Sprintf("[消息队列]监听器delay异常，进行重启."))
+		}
+	}
 }
 
 // 队列监听(延迟)
 func (s *Queue) monitorDelay(topic string) {
 	topicDelay := s.getDelayTopic(topic)
-	for {
+	for !s.stopped() {
 		r, err := s.Config.RedisApi.Client.ZRangeWithScores(topicDelay, 0, 0).Result()
 		if err != nil {
 			panic(err.Error())
@@ -207,10 +297,10 @@ func (s *Queue) monitorDelay(topic string) {
 				// 暂停等待剩余时间
 				if remainingTime.Seconds() < float64(*s.Config.SortedSetRestTime) {
 					// 剩余时间小于休息时间，则按剩余时间暂停
-					time.Sleep(remainingTime)
+					s.sleep(remainingTime)
 				} else {
 					// 否则直接用休息时间暂停
-					time.Sleep(time.Second * time.Duration(*s.Config.SortedSetRestTime))
+					s.sleep(time.Second * time.Duration(*s.Config.SortedSetRestTime))
 				}
 			} else {
 				// 删除记录有序集合中的记录
@@ -220,7 +310,7 @@ func (s *Queue) monitorDelay(topic string) {
 			}
 		} else {
 			// 有序集合中没有消息时休息等待
-			time.Sleep(time.Second * time.Duration(*s.Config.SortedSetRestTime))
+			s.sleep(time.Second * time.Duration(*s.Config.SortedSetRestTime))
 		}
 	}
 }
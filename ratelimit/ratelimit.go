@@ -0,0 +1,284 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：基于Redis的限流
+//
+// 滑动窗口：Window 内最多 Limit 次请求，按请求时间精确计算
+// 令牌桶：桶容量 Limit，每 Window 补满，允许短时突发
+// 计数在Redis中通过Lua脚本原子执行，多实例部署共享限额；Redis异常时放行并记录日志
+//
+// 用法：
+//
+//	login := r.Group("/login", ratelimit.Middleware(redisApi, ratelimit.Rule{
+//		Name: "login", Limit: 5, Window: time.Minute, Key: ratelimit.ByIp,
+//	}))
+//
+// 或在 router.Hook.RateLimit 中按路由前缀统一配置
+// *****************************************************************************
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/go-redis/redis"
+	"github.com/lgdzz/vingo-utils-v3/redis"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+const (
+	SlidingWindow = "sliding" // 滑动窗口
+	TokenBucket   = "bucket"  // 令牌桶
+)
+
+// KeyFunc 限流维度，返回空字符串时不限流
+type KeyFunc func(c *vingo.Context) string
+
+// ByIp 按客户端IP
+func ByIp(c *vingo.Context) string {
+	return "ip:" + clientIp(c)
+}
+
+// ByUser 按登录用户，未登录时按IP
+func ByUser(c *vingo.Context) string {
+	if id := c.GetUserId(); id > 0 {
+		return "user:" + strconv.Itoa(id)
+	}
+	return ByIp(c)
+}
+
+// ByRoute 按路由，所有客户端共享限额
+func ByRoute(c *vingo.Context) string {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	return "route:" + c.Request.Method + ":" + path
+}
+
+// ByIpRoute 按客户端IP和路由
+func ByIpRoute(c *vingo.Context) string {
+	return ByRoute(c) + ":" + ByIp(c)
+}
+
+type Rule struct {
+	Name      string        // 规则名称，用于区分Redis键，为空时按作用范围、算法和限额生成；多个路由组使用相同限额时需指定不同名称或 Scope
+	Scope     string        // 作用范围，参与默认名称生成，Hook.RateLimit 中为路由前缀
+	Algorithm string        // 算法：sliding|bucket，默认sliding
+	Limit     int           // 滑动窗口为窗口内最大请求数，令牌桶为桶容量
+	Window    time.Duration // 滑动窗口为窗口时长，令牌桶为补满令牌的时长
+	Key       KeyFunc       // 限流维度，默认按IP
+	Allow     []string      // 白名单，客户端IP（支持CIDR）或 Key 返回值
+	Message   string        // 限流提示，默认：请求过于频繁，请稍后再试
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	api      *redis.Api
+	rule     Rule
+	networks []*net.IPNet
+	allows   map[string]bool
+}
+
+// New 新建限流器
+func New(api *redis.Api, rule Rule) *Limiter {
+	if api == nil {
+		panic("限流需要配置redis")
+	}
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		panic("限流规则 Limit、Window 必须大于0")
+	}
+	if rule.Algorithm == "" {
+		rule.Algorithm = SlidingWindow
+	}
+	if rule.Algorithm != SlidingWindow && rule.Algorithm != TokenBucket {
+		panic(fmt.Sprintf("限流算法不支持：%v", rule.Algorithm))
+	}
+	if rule.Key == nil {
+		rule.Key = ByIp
+	}
+	if rule.Name == "" {
+		rule.Name = fmt.Sprintf("%v:%d:%d", rule.Algorithm, rule.Limit, rule.Window.Milliseconds())
+		if rule.Scope != "" {
+			rule.Name = rule.Scope + ":" + rule.Name
+		}
+	}
+	if rule.Message == "" {
+		rule.Message = "请求过于频繁，请稍后再试"
+	}
+	limiter := &Limiter{api: api, rule: rule, allows: map[string]bool{}}
+	for _, item := range rule.Allow {
+		item = strings.TrimSpace(item)
+		if _, network, err := net.ParseCIDR(item); err == nil {
+			limiter.networks = append(limiter.networks, network)
+		} else if ip := net.ParseIP(item); ip != nil {
+			limiter.networks = append(limiter.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		} else {
+			limiter.allows[item] = true
+		}
+	}
+	return limiter
+}
+
+// slidingScript 有序集合记录窗口内的请求，返回 {是否允许, 剩余次数, 重试等待毫秒}
+var slidingScript = goredis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[3])
+	redis.call('PEXPIRE', key, window)
+	return {1, limit - count - 1, 0}
+end
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local retry = window
+if oldest[2] then
+	retry = tonumber(oldest[2]) + window - now
+end
+return {0, 0, retry}
+`)
+
+// bucketScript 哈希记录令牌数和更新时间，返回 {是否允许, 剩余令牌, 重试等待毫秒}
+var bucketScript = goredis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local rate = capacity / window
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local data = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(data[1]) or capacity
+local ts = tonumber(data[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, window)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// Take 消耗一次请求额度
+func (s *Limiter) Take(key string) (Result, error) {
+	redisKey := s.api.Config.Prefix + "ratelimit:" + s.rule.Name + ":" + key
+	window := s.rule.Window.Milliseconds()
+	var cmd *goredis.Cmd
+	if s.rule.Algorithm == TokenBucket {
+		cmd = bucketScript.Run(s.api.Client, []string{redisKey}, s.rule.Limit, window)
+	} else {
+		cmd = slidingScript.Run(s.api.Client, []string{redisKey}, s.rule.Limit, window, vingo.GetUUID())
+	}
+	value, err := cmd.Result()
+	if err != nil {
+		return Result{Allowed: true}, err
+	}
+	values, ok := value.([]interface{})
+	if !ok || len(values) != 3 {
+		return Result{Allowed: true}, fmt.Errorf("限流脚本返回值错误：%v", value)
+	}
+	return Result{
+		Allowed:    toInt64(values[0]) == 1,
+		Remaining:  int(toInt64(values[1])),
+		RetryAfter: time.Duration(toInt64(values[2])) * time.Millisecond,
+	}, nil
+}
+
+func toInt64(value any) int64 {
+	if v, ok := value.(int64); ok {
+		return v
+	}
+	return 0
+}
+
+// allowed 是否在白名单中
+func (s *Limiter) allowed(c *vingo.Context, key string) bool {
+	if s.allows[key] {
+		return true
+	}
+	if len(s.networks) == 0 {
+		return false
+	}
+	ip := net.ParseIP(clientIp(c))
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 检查请求，超出限制时返回429并中止请求
+func (s *Limiter) Check(c *gin.Context) bool {
+	ctx := &vingo.Context{Context: c}
+	key := s.rule.Key(ctx)
+	if key == "" || s.allowed(ctx, key) {
+		return true
+	}
+	result, err := s.Take(key)
+	if err != nil {
+		// Redis异常时放行，避免影响正常业务
+		vingo.LogError(fmt.Sprintf("[限流]%v执行失败：%v", s.rule.Name, err))
+		return true
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(s.rule.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if result.Allowed {
+		return true
+	}
+	seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	ctx.Response(&vingo.ResponseData{Message: s.rule.Message, Status: 429, Error: 1, ErrorType: "限流"})
+	c.Abort()
+	return false
+}
+
+// Middleware 限流中间件，多个规则需全部通过
+func Middleware(api *redis.Api, rules ...Rule) gin.HandlerFunc {
+	limiters := make([]*Limiter, 0, len(rules))
+	for _, rule := range rules {
+		limiters = append(limiters, New(api, rule))
+	}
+	return func(c *gin.Context) {
+		for _, limiter := range limiters {
+			if !limiter.Check(c) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// clientIp 客户端IP，去除端口
+func clientIp(c *vingo.Context) string {
+	ip := strings.TrimSpace(c.GetRealClientIP())
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
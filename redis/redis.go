@@ -7,8 +7,11 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-redis/redis"
+	"github.com/lgdzz/vingo-utils-v3/metrics"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
 )
 
 // NewRedis 新建一个redis连接池
@@ -40,5 +43,22 @@ func NewRedis(config Config) *Api {
 		fmt.Println(fmt.Sprintf("Redis连接异常：%v", err.Error()))
 	}
 
+	// 停机时关闭连接池
+	vingo.RegisterShutdown(fmt.Sprintf("redis[%v:%v]", config.Host, config.Port), vingo.ShutdownStorage, func(ctx context.Context) error {
+		return api.Client.Close()
+	})
+
+	// 连接池指标
+	labels := metrics.Labels{"redis": fmt.Sprintf("%v:%v/%v", config.Host, config.Port, config.Select)}
+	metrics.RegisterCollector(func(w *metrics.Writer) {
+		stats := api.Client.PoolStats()
+		w.Gauge("redis_pool_total_connections", "当前连接数", labels, float64(stats.TotalConns))
+		w.Gauge("redis_pool_idle_connections", "空闲连接数", labels, float64(stats.IdleConns))
+		w.Counter("redis_pool_hits_total", "复用连接次数", labels, float64(stats.Hits))
+		w.Counter("redis_pool_misses_total", "新建连接次数", labels, float64(stats.Misses))
+		w.Counter("redis_pool_timeouts_total", "获取连接超时次数", labels, float64(stats.Timeouts))
+		w.Counter("redis_pool_stale_connections_total", "关闭的失效连接数", labels, float64(stats.StaleConns))
+	})
+
 	return &api
 }
@@ -22,6 +22,7 @@ type Option struct {
 	Timeout        *int
 	FileFieldName  *string
 	FileOtherField *map[string]string
+	Signer         *Signer // 开放接口签名，在设置请求头之后执行
 }
 
 func NewOption(opt *Option) Option {
@@ -42,6 +43,9 @@ func NewOption(opt *Option) Option {
 		if opt.FileOtherField != nil {
 			def.FileOtherField = opt.FileOtherField
 		}
+		if opt.Signer != nil {
+			def.Signer = opt.Signer
+		}
 	}
 	return def
 }
@@ -53,6 +57,7 @@ func Get(url string, opt Option) ([]byte, *http.Response) {
 		panic(err)
 	}
 	setHeaders(req, opt.Headers)
+	signRequest(req, opt.Signer)
 	return doRequest(req, *opt.Timeout)
 }
 
@@ -68,6 +73,7 @@ func PostJSON(url string, body interface{}, opt Option) ([]byte, *http.Response)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	setHeaders(req, opt.Headers)
+	signRequest(req, opt.Signer)
 	return doRequest(req, *opt.Timeout)
 }
 
@@ -87,6 +93,7 @@ func PostFormData(url string, form map[string]string, opt Option) ([]byte, *http
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	setHeaders(req, opt.Headers)
+	signRequest(req, opt.Signer)
 
 	return doRequest(req, *opt.Timeout)
 }
@@ -104,6 +111,7 @@ func PostFormURLEncoded(urlPath string, form map[string]string, opt Option) ([]b
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	setHeaders(req, opt.Headers)
+	signRequest(req, opt.Signer)
 
 	return doRequest(req, *opt.Timeout)
 }
@@ -144,6 +152,7 @@ func PostFile(url string, opt Option, filePath string) ([]byte, *http.Response)
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	setHeaders(req, opt.Headers)
+	signRequest(req, opt.Signer)
 
 	return doRequest(req, *opt.Timeout)
 }
@@ -161,6 +170,7 @@ func PostJSONStream(url string, body interface{}, opt Option, receive func(...by
 	}
 	req.Header.Set("Content-Type", "application/json")
 	setHeaders(req, opt.Headers)
+	signRequest(req, opt.Signer)
 
 	client := &http.Client{
 		Timeout: time.Duration(*opt.Timeout) * time.Second,
@@ -0,0 +1,32 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：请求签名，调用使用 sign 包校验的开放接口
+// *****************************************************************************
+
+package request
+
+import (
+	"net/http"
+
+	"github.com/lgdzz/vingo-utils-v3/sign"
+)
+
+type Signer struct {
+	AppKey    string
+	Secret    string
+	Algorithm string // sign.HmacSha256|sign.HmacSm3，默认HMAC-SHA256
+}
+
+// Sign 为请求添加签名请求头
+func (s *Signer) Sign(req *http.Request) {
+	if err := sign.Sign(req, s.AppKey, s.Secret, s.Algorithm); err != nil {
+		panic(err)
+	}
+}
+
+func signRequest(req *http.Request, signer *Signer) {
+	if signer != nil {
+		signer.Sign(req)
+	}
+}
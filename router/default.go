@@ -1,10 +1,16 @@
 package router
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/duke-git/lancet/v2/maputil"
@@ -13,6 +19,8 @@ import (
 	"github.com/lgdzz/vingo-utils-v3/cli"
 	"github.com/lgdzz/vingo-utils-v3/db"
 	"github.com/lgdzz/vingo-utils-v3/moment"
+	"github.com/lgdzz/vingo-utils-v3/openapi"
+	"github.com/lgdzz/vingo-utils-v3/ratelimit"
 	"github.com/lgdzz/vingo-utils-v3/redis"
 	"github.com/lgdzz/vingo-utils-v3/vingo"
 	"github.com/shirou/gopsutil/v3/process"
@@ -22,9 +30,21 @@ type Hook struct {
 	Option         HookOption
 	RegisterRouter func(r *gin.Engine)
 	BaseMiddle     func(c *gin.Context)
-	LoadWeb        []WebItem           // 通过此配置将前端项目打包到项目中
-	AllowMethods   map[string]struct{} // 默认支持get、post，如需额外其他方法在此处增加
+	LoadWeb        []WebItem                 // 通过此配置将前端项目打包到项目中
+	AllowMethods   map[string]struct{}       // 默认支持get、post，如需额外其他方法在此处增加
+	OnStart        func()                    // 服务开始监听后执行
+	OnShutdown     func(ctx context.Context) // 收到退出信号且请求处理完成后执行，之后按顺序关闭已注册的组件
+	RateLimit      *RateLimitOption          // 按路由前缀限流
+}
 
+// RateLimitOption 按路由前缀限流
+// Hook.RateLimit 在 Hook.BaseMiddle 之后、路由组中间件之前执行，ratelimit.ByUser 只能识别在 Hook.BaseMiddle 中完成的登录认证；
+// 认证在路由组中间件中完成时不要配置 Hook.RateLimit，改为在认证中间件之后使用 RateLimit(option)：
+//
+//	api := r.Group("/api", authMiddle, router.RateLimit(option))
+type RateLimitOption struct {
+	Redis  *redis.Api
+	Groups map[string][]ratelimit.Rule // 路由前缀 -> 限流规则，按完整路径段匹配，匹配的前缀全部生效，如 "/" 对所有请求生效
 }
 
 type HookOption struct {
@@ -32,8 +52,18 @@ type HookOption struct {
 	Port      int
 	Copyright string
 	Debug     bool
-	Database  *db.Api
+	Database  *db.Api // 单数据库项目使用，通过 db.Open 注册多个连接时启动信息列出所有连接
 	Redis     *redis.Config
+	// 停机时等待处理中请求的时间，默认15秒
+	ShutdownTimeout time.Duration
+	// 停机时 OnShutdown 和已注册组件关闭的等待时间，与请求等待时间分别计算，默认15秒
+	ComponentTimeout time.Duration
+	// 开启HTTPS，证书使用 /ssl.deploy 部署的文件，更新后自动生效
+	Tls *TlsOption
+	// 证书部署接口 /ssl.deploy 配置，为空时不注册该接口
+	Ssl *SslOption
+	// 指标接口 /metrics 配置，为空时不注册该接口
+	Metrics   *MetricsOption
 	startTime time.Time // 启动时间
 }
 
@@ -84,13 +114,21 @@ func InitRouter(hook *Hook) {
 	// 注册异常处理、基础中间件
 	r.Use(vingo.ExceptionHandler, BaseMiddle(hook))
 
+	// 限流
+	if hook.RateLimit != nil {
+		r.Use(rateLimitMiddle(hook.RateLimit))
+	}
+
 	// 注册基础路由
 	r.GET("/", func(c *gin.Context) {
 		Console(c, option)
 	})
 
 	// SSL证书
-	ssl(r)
+	ssl(r, option)
+
+	// 指标
+	metricsRoute(r, option)
 
 	r.GET("/favicon.ico", func(c *gin.Context) {
 		c.Status(204) // No Content
@@ -103,12 +141,22 @@ func InitRouter(hook *Hook) {
 	}
 	hook.RegisterRouter(r)
 
+	// 调试模式下提供接口文档，文档内容来自 openapi.Get/Post 等注册的路由
+	if option.Debug {
+		openapi.Mount(r, openapi.Info{Title: option.Name, Version: cli.Version})
+	}
+
 	fmt.Println("+------------------------------------------------------------+")
 	fmt.Println(fmt.Sprintf("+ 项目名称：%v", option.Name))
 	fmt.Println(fmt.Sprintf("+ 服务端口：%d", option.Port))
 	fmt.Println(fmt.Sprintf("+ 调试模式：%v", option.Debug))
-	if option.Database != nil {
-		fmt.Println(fmt.Sprintf("+ %v：%v:%v db:%v", option.Database.Config.Driver, option.Database.Config.Host, option.Database.Config.Port, option.Database.Config.Dbname))
+	if names := db.Names(); len(names) > 0 {
+		for _, name := range names {
+			api := db.Get(name)
+			fmt.Println(fmt.Sprintf("+ %v[%v]：%v", api.Config.Driver, name, api.Address()))
+		}
+	} else if option.Database != nil {
+		fmt.Println(fmt.Sprintf("+ %v：%v", option.Database.Config.Driver, option.Database.Address()))
 	}
 	if option.Redis != nil {
 		fmt.Println(fmt.Sprintf("+ redis：%v:%v db:%v prefix:%v", option.Redis.Host, option.Redis.Port, option.Redis.Select, option.Redis.Prefix))
@@ -119,13 +167,107 @@ func InitRouter(hook *Hook) {
 
 	option.startTime = time.Now()
 
-	vingo.ApiAddress(option.Port)
+	if option.Tls != nil {
+		vingo.ApiAddress(option.Port, "https")
+		if option.Tls.RedirectPort > 0 {
+			fmt.Println(fmt.Sprintf("+ HTTP跳转：%d -> %d", option.Tls.RedirectPort, option.Port))
+		}
+	} else {
+		vingo.ApiAddress(option.Port)
+	}
+	if option.Debug {
+		fmt.Println(fmt.Sprintf("+ 接口文档：/openapi.json，Swagger UI：/swagger（资源地址：%v）", openapi.SwaggerAssets))
+	}
 	fmt.Println(fmt.Sprintf("+ 启动时间：%v", option.startTime.Format(moment.DateTimeFormat)))
 	fmt.Println(fmt.Sprintf("+ 技术支持：%v", option.Copyright))
 	fmt.Println("+------------------------------------------------------------+")
 
 	// 开启服务
-	_ = r.Run(fmt.Sprintf(":%d", option.Port))
+	server := &http.Server{Addr: fmt.Sprintf(":%d", option.Port), Handler: r}
+	servers := []*http.Server{server}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		fmt.Println(fmt.Sprintf("服务启动失败：%v", err))
+		return
+	}
+	serveErr := make(chan error, 2)
+	if option.Tls != nil {
+		certs = newCertStore(option.Tls.Dir)
+	}
+	if option.Tls != nil && !certs.loaded() && option.Tls.RedirectPort == 0 {
+		// 没有证书且没有HTTP端口时无法部署证书，主端口降级为HTTP
+		fmt.Println("+ 未找到HTTPS证书，当前以HTTP提供服务，部署证书后重启生效")
+		vingo.LogError("[HTTPS]未找到证书，当前以HTTP提供服务，部署证书后重启生效")
+		go func() {
+			serveErr <- server.Serve(listener)
+		}()
+	} else if option.Tls != nil {
+		server.TLSConfig = &tls.Config{GetCertificate: certs.GetCertificate, MinVersion: tls.VersionTLS12}
+		stopWatch := make(chan struct{})
+		go certs.watch(option.Tls.CheckInterval, stopWatch)
+		vingo.RegisterShutdown("证书检查", vingo.ShutdownApp, func(ctx context.Context) error {
+			close(stopWatch)
+			return nil
+		})
+		go func() {
+			serveErr <- server.ServeTLS(listener, "", "")
+		}()
+
+		if option.Tls.RedirectPort > 0 {
+			redirect := redirectServer(option.Tls.RedirectPort, option.Port, r)
+			servers = append(servers, redirect)
+			go func() {
+				serveErr <- redirect.ListenAndServe()
+			}()
+		}
+	} else {
+		go func() {
+			serveErr <- server.Serve(listener)
+		}()
+	}
+	if hook.OnStart != nil {
+		hook.OnStart()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Println(fmt.Sprintf("服务启动失败：%v", err))
+		}
+	case sig := <-quit:
+		fmt.Println(fmt.Sprintf("收到信号[%v]，开始停机", sig))
+	}
+	shutdown(hook, servers, option.ShutdownTimeout, option.ComponentTimeout)
+}
+
+// shutdown 停止接收新请求并等待处理中的请求完成，再执行 OnShutdown 和已注册组件的关闭
+// 请求等待超时不影响组件关闭的时间，避免慢请求占满时间后组件来不及关闭
+func shutdown(hook *Hook, servers []*http.Server, timeout time.Duration, componentTimeout time.Duration) {
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	if componentTimeout <= 0 {
+		componentTimeout = 15 * time.Second
+	}
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), timeout)
+	defer cancelDrain()
+	for _, server := range servers {
+		if err := server.Shutdown(drainCtx); err != nil {
+			fmt.Println(fmt.Sprintf("[停机]等待请求处理超时：%v", err))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), componentTimeout)
+	defer cancel()
+	if hook.OnShutdown != nil {
+		hook.OnShutdown(ctx)
+	}
+	vingo.Shutdown(ctx)
+	fmt.Println("[停机]完成")
 }
 
 func Console(c *gin.Context, option HookOption) {
@@ -0,0 +1,55 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：Prometheus 指标接口
+// *****************************************************************************
+
+package router
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lgdzz/vingo-utils-v3/metrics"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+type MetricsOption struct {
+	Path       string   // 接口路径，默认 /metrics
+	Token      string   // 非空时需携带请求头 Authorization: Bearer {Token}
+	AllowIps   []string // 允许访问的IP或网段，为空时不限制
+	TrustProxy bool     // 通过代理访问时按 X-Forwarded-For 判断IP
+}
+
+// metricsRoute 注册指标接口，未配置时不注册
+func metricsRoute(r *gin.Engine, option HookOption) {
+	config := option.Metrics
+	if config == nil {
+		return
+	}
+	if config.Path == "" {
+		config.Path = "/metrics"
+	}
+	allows := parseAllowIps(config.AllowIps)
+	handler := metrics.Handler()
+
+	r.GET(config.Path, func(c *gin.Context) {
+		ip := sslClientIp(c, config.TrustProxy)
+		if len(allows) > 0 && !ipAllowed(ip, allows) {
+			vingo.LogError(fmt.Sprintf("[指标]拒绝访问，IP不在白名单：%v", ip))
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		if config.Token != "" {
+			token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(config.Token)) != 1 {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -2,12 +2,17 @@ package router
 
 import (
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lgdzz/vingo-utils-v3/ratelimit"
 	"github.com/lgdzz/vingo-utils-v3/vingo"
 )
 
+// BaseMiddle 记录请求开始时间和标识，执行 Hook.BaseMiddle，请求处理完成后记录接口指标
+// 后续处理中的异常在这里转为响应，保证记录指标时响应状态已确定
 func BaseMiddle(hook *Hook) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		method := c.Request.Method
@@ -17,9 +22,18 @@ func BaseMiddle(hook *Hook) gin.HandlerFunc {
 			c.Set("requestStart", startTime)
 			c.Set("requestUUID", vingo.GetUUID())
 
-			if hook.BaseMiddle != nil {
-				hook.BaseMiddle(c)
-			}
+			func() {
+				defer func() {
+					if err := recover(); err != nil {
+						vingo.RecoverResponse(c, err)
+					}
+				}()
+				if hook.BaseMiddle != nil {
+					hook.BaseMiddle(c)
+				}
+				c.Next()
+			}()
+			(&vingo.Context{Context: c}).ObserveRequest(startTime)
 		} else {
 			// 禁止的方法
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
@@ -29,3 +43,51 @@ func BaseMiddle(hook *Hook) gin.HandlerFunc {
 		}
 	}
 }
+
+// matchPrefix 按完整路径段匹配前缀，/login 匹配 /login、/login/sms，不匹配 /loginx
+func matchPrefix(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// RateLimit 按路由前缀限流的中间件，用于在认证中间件之后执行，使 ratelimit.ByUser 按登录用户限流
+// 同一配置只能使用一次（Hook.RateLimit 或 RateLimit），否则重复计数
+func RateLimit(option *RateLimitOption) gin.HandlerFunc {
+	return rateLimitMiddle(option)
+}
+
+// rateLimitMiddle 按路由前缀限流，前缀短的规则先执行
+func rateLimitMiddle(option *RateLimitOption) gin.HandlerFunc {
+	prefixes := make([]string, 0, len(option.Groups))
+	limiters := map[string][]*ratelimit.Limiter{}
+	for prefix, rules := range option.Groups {
+		prefixes = append(prefixes, prefix)
+		for _, rule := range rules {
+			if rule.Scope == "" {
+				// 不同前缀的相同限额使用各自的计数
+				rule.Scope = prefix
+			}
+			limiters[prefix] = append(limiters[prefix], ratelimit.New(option.Redis, rule))
+		}
+	}
+	sort.Strings(prefixes)
+	return func(c *gin.Context) {
+		if c.IsAborted() {
+			return
+		}
+		path := c.Request.URL.Path
+		for _, prefix := range prefixes {
+			if !matchPrefix(path, prefix) {
+				continue
+			}
+			for _, limiter := range limiters[prefix] {
+				if !limiter.Check(c) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -2,58 +2,422 @@
 // 作者: lgdz
 // 创建时间: 2026/6/22
 // 描述：SSL证书部署
+//
+// 配置 HookOption.Ssl 后注册 /ssl.input、/ssl.deploy，请求需通过以下认证之一：
+// 令牌：请求头 X-Ssl-Token 等于 SslOption.Token
+// 签名：请求头 X-Ssl-Timestamp 为秒级时间戳（5分钟内有效），
+// X-Ssl-Signature 为 hex(HMAC-SHA256(SslOption.Secret, timestamp + "\n" + 请求体))
+//
+// 部署前校验证书与私钥匹配、包含请求的域名且在有效期内，原证书备份到 {证书目录}/backup，
+// 加载新证书或执行重启命令失败时自动恢复原证书。
+// 重启命令只能从 SslOption.Restart 中按名称选择，请求中 restart_cmd 填写名称。
 // *****************************************************************************
 
 package router
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lgdzz/vingo-utils-v3/moment"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
 )
 
 type SslInput struct {
 	Domain                  string `json:"domain"`
-	Domains                 string `json:"domains"`
+	Domains                 string `json:"domains"` // 多个域名逗号分隔
 	Certificate             string `json:"certificate"`
 	PrivateKey              string `json:"private_key"`
-	ServerCertificate       string `json:"server_certificate"`
-	IntermediateCertificate string `json:"intermediate_certificate"`
-	RestartCmd              string `json:"restart_cmd"`
+	ServerCertificate       string `json:"server_certificate"`       // Certificate 为空时使用 服务器证书+中间证书
+	IntermediateCertificate string `json:"intermediate_certificate"` // 中间证书
+	RestartCmd              string `json:"restart_cmd"`              // SslOption.Restart 中的命令名称
 }
 
+type SslOption struct {
+	Token      string            // 共享令牌
+	Secret     string            // HMAC签名密钥
+	AllowIps   []string          // IP白名单，支持CIDR，为空时不限制
+	TrustProxy bool              // 是否信任代理转发的客户端IP，服务部署在反向代理后时开启
+	Restart    map[string]string // 允许执行的重启命令：名称 -> 命令
+	Dir        string            // 证书目录，默认与 HookOption.Tls 相同，未开启HTTPS时为 ssl
+	Backups    int               // 保留的备份数量，默认5
+}
+
+const (
+	sslTokenHeader     = "X-Ssl-Token"
+	sslTimestampHeader = "X-Ssl-Timestamp"
+	sslSignatureHeader = "X-Ssl-Signature"
+	sslSignatureWindow = 5 * time.Minute
+	sslRestartTimeout  = time.Minute
+)
+
+// sslDeploying 同一时间只允许一个部署
+var sslDeploying sync.Mutex
+
 func saveFile(name string, content string) {
-	err := os.WriteFile(name, []byte(content), 0644)
-	if err != nil {
+	// 先写临时文件再重命名，避免读取到写了一半的证书
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		panic(err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
 		panic(err)
 	}
 }
 
-func ssl(r *gin.Engine) {
-	r.GET("/ssl.input", func(c *gin.Context) {
-		c.JSON(200, SslInput{})
+func ssl(r *gin.Engine, option HookOption) {
+	if option.Ssl == nil {
+		return
+	}
+	config := *option.Ssl
+	if config.Token == "" && config.Secret == "" {
+		panic("SslOption 需配置 Token 或 Secret")
+	}
+	if config.Dir == "" {
+		config.Dir = "ssl"
+		if option.Tls != nil && option.Tls.Dir != "" {
+			config.Dir = option.Tls.Dir
+		}
+	}
+	if config.Backups <= 0 {
+		config.Backups = 5
+	}
+	allows := parseAllowIps(config.AllowIps)
+
+	auth := func(c *gin.Context) {
+		ip := sslClientIp(c, config.TrustProxy)
+		if len(allows) > 0 && !ipAllowed(ip, allows) {
+			vingo.LogError(fmt.Sprintf("[证书部署]拒绝访问，IP不在白名单：%v", ip))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+		body, err := c.GetRawData()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !sslAuthorized(c.Request, body, config) {
+			vingo.LogError(fmt.Sprintf("[证书部署]认证失败，IP：%v", ip))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Set("sslBody", body)
+	}
+
+	r.GET("/ssl.input", auth, func(c *gin.Context) {
+		names := make([]string, 0, len(config.Restart))
+		for name := range config.Restart {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.JSON(200, gin.H{"input": SslInput{}, "restart": names})
 	})
 
-	r.POST("/ssl.deploy", func(c *gin.Context) {
+	r.POST("/ssl.deploy", auth, func(c *gin.Context) {
 		var input SslInput
-		if err := c.ShouldBindJSON(&input); err != nil {
+		if err := json.Unmarshal(c.MustGet("sslBody").([]byte), &input); err != nil {
 			panic(err.Error())
 		}
 
-		saveFile("ssl/fullchain.pem", input.Certificate)
-		saveFile("ssl/privkey.pem", input.PrivateKey)
+		sslDeploying.Lock()
+		defer sslDeploying.Unlock()
+
+		result, err := deployCertificate(config, input)
+		ip := sslClientIp(c, config.TrustProxy)
+		if err != nil {
+			vingo.LogError(fmt.Sprintf("[证书部署]失败，IP：%v，域名：%v，原因：%v", ip, input.Domain, err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		vingo.LogInfo(fmt.Sprintf("[证书部署]成功，IP：%v，域名：%v，到期时间：%v", ip, strings.Join(result.Domains, ","), result.NotAfter))
+		c.JSON(200, result)
+	})
+}
 
-		if input.RestartCmd != "" {
-			cmdText := strings.Split(strings.TrimSpace(input.RestartCmd), " ")
-			cmd := exec.Command(cmdText[0], cmdText[1:]...)
-			_, err := cmd.CombinedOutput()
-			if err != nil {
-				panic(err)
+// sslAuthorized 令牌或签名任一通过即可
+func sslAuthorized(request *http.Request, body []byte, config SslOption) bool {
+	if config.Token != "" {
+		token := request.Header.Get(sslTokenHeader)
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(config.Token)) == 1 {
+			return true
+		}
+	}
+	if config.Secret != "" {
+		timestamp := request.Header.Get(sslTimestampHeader)
+		signature := request.Header.Get(sslSignatureHeader)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || signature == "" {
+			return false
+		}
+		if diff := time.Since(time.Unix(seconds, 0)); diff > sslSignatureWindow || diff < -sslSignatureWindow {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(config.Secret))
+		mac.Write([]byte(timestamp + "\n"))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected))
+	}
+	return false
+}
+
+func sslClientIp(c *gin.Context, trustProxy bool) string {
+	ip := c.Request.RemoteAddr
+	if trustProxy {
+		ctx := vingo.Context{Context: c}
+		ip = ctx.GetRealClientIP()
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return strings.TrimSpace(ip)
+}
+
+func parseAllowIps(items []string) []*net.IPNet {
+	var result []*net.IPNet
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if !strings.Contains(item, "/") {
+			if strings.Contains(item, ":") {
+				item += "/128"
+			} else {
+				item += "/32"
 			}
 		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			panic(fmt.Sprintf("AllowIps 格式错误：%v", item))
+		}
+		result = append(result, network)
+	}
+	return result
+}
 
-		c.JSON(200, gin.H{})
-	})
+func ipAllowed(ip string, allows []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range allows {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+type SslResult struct {
+	Domains  []string `json:"domains"`
+	NotAfter string   `json:"notAfter"`
+	Backup   string   `json:"backup,omitempty"`
+	Output   string   `json:"output,omitempty"` // 重启命令输出
+}
+
+// validateCertificate 校验证书与私钥匹配、包含域名、在有效期内
+func validateCertificate(input SslInput) (string, []string, tls.Certificate, error) {
+	certificate := strings.TrimSpace(input.Certificate)
+	if certificate == "" {
+		certificate = strings.TrimSpace(input.ServerCertificate)
+		if intermediate := strings.TrimSpace(input.IntermediateCertificate); intermediate != "" {
+			certificate += "\n" + intermediate
+		}
+	}
+	certificate += "\n"
+
+	var domains []string
+	for _, domain := range strings.Split(input.Domain+","+input.Domains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return "", nil, tls.Certificate{}, fmt.Errorf("域名不能为空")
+	}
+
+	pair, err := tls.X509KeyPair([]byte(certificate), []byte(input.PrivateKey))
+	if err != nil {
+		return "", nil, pair, fmt.Errorf("证书与私钥不匹配或格式错误：%w", err)
+	}
+	leaf := pair.Leaf
+	if leaf == nil {
+		if leaf, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+			return "", nil, pair, fmt.Errorf("证书格式错误：%w", err)
+		}
+		pair.Leaf = leaf
+	}
+	now := time.Now()
+	if now.Before(leaf.NotBefore) {
+		return "", nil, pair, fmt.Errorf("证书未生效，生效时间：%v", leaf.NotBefore.Local())
+	}
+	if now.After(leaf.NotAfter) {
+		return "", nil, pair, fmt.Errorf("证书已过期，到期时间：%v", leaf.NotAfter.Local())
+	}
+	for _, domain := range domains {
+		if err = leaf.VerifyHostname(domain); err != nil {
+			return "", nil, pair, fmt.Errorf("证书不包含域名[%v]", domain)
+		}
+	}
+	return certificate, domains, pair, nil
+}
+
+func deployCertificate(config SslOption, input SslInput) (*SslResult, error) {
+	// 先校验再写入，任何错误都不影响当前证书
+	certificate, domains, pair, err := validateCertificate(input)
+	if err != nil {
+		return nil, err
+	}
+	var restart string
+	if input.RestartCmd != "" {
+		var ok bool
+		if restart, ok = config.Restart[input.RestartCmd]; !ok {
+			return nil, fmt.Errorf("重启命令未配置：%v", input.RestartCmd)
+		}
+	}
+
+	if err = os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, err
+	}
+	result := &SslResult{Domains: domains, NotAfter: pair.Leaf.NotAfter.Local().Format(moment.DateTimeFormat)}
+	if result.Backup, err = backupCertificate(config); err != nil {
+		return nil, fmt.Errorf("备份原证书失败：%w", err)
+	}
+
+	if err = writeCertificate(config.Dir, certificate, input.PrivateKey); err == nil {
+		err = reloadCertificate(config.Dir)
+	}
+	if err == nil && restart != "" {
+		result.Output, err = runRestart(restart)
+	}
+	if err != nil {
+		if result.Backup == "" {
+			// 首次部署没有原证书，删除写入的文件
+			if removeErr := removeCertificate(config.Dir); removeErr != nil {
+				return nil, fmt.Errorf("部署失败：%v；删除已写入的证书失败：%v", err, removeErr)
+			}
+			if certs != nil {
+				_ = certs.reload()
+			}
+			return nil, fmt.Errorf("部署失败，已删除写入的证书：%w", err)
+		}
+		if rollbackErr := restoreCertificate(config.Dir, result.Backup); rollbackErr != nil {
+			return nil, fmt.Errorf("%v；恢复原证书失败：%v", err, rollbackErr)
+		}
+		_ = reloadCertificate(config.Dir)
+		if restart != "" {
+			_, _ = runRestart(restart)
+		}
+		return nil, fmt.Errorf("部署失败，已恢复原证书：%w", err)
+	}
+	pruneBackups(config)
+	return result, nil
+}
+
+func writeCertificate(dir string, certificate string, key string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	saveFile(filepath.Join(dir, keyFile), key)
+	saveFile(filepath.Join(dir, certFile), certificate)
+	return nil
+}
+
+// removeCertificate 删除证书文件，文件不存在时忽略
+func removeCertificate(dir string) error {
+	for _, name := range []string{keyFile, certFile} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupCertificate 备份当前证书，没有证书时返回空
+func backupCertificate(config SslOption) (string, error) {
+	if !isFile(filepath.Join(config.Dir, certFile)) {
+		return "", nil
+	}
+	backup := filepath.Join(config.Dir, "backup", time.Now().Format("20060102150405.000"))
+	if err := os.MkdirAll(backup, 0700); err != nil {
+		return "", err
+	}
+	for _, name := range []string{certFile, keyFile} {
+		if err := copyFile(filepath.Join(config.Dir, name), filepath.Join(backup, name)); err != nil {
+			return "", err
+		}
+	}
+	return backup, nil
+}
+
+func restoreCertificate(dir string, backup string) error {
+	for _, name := range []string{keyFile, certFile} {
+		if err := copyFile(filepath.Join(backup, name), filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src string, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	if err = os.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// pruneBackups 只保留最近的备份
+func pruneBackups(config SslOption) {
+	entries, err := os.ReadDir(filepath.Join(config.Dir, "backup"))
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > config.Backups {
+		_ = os.RemoveAll(filepath.Join(config.Dir, "backup", names[0]))
+		names = names[1:]
+	}
+}
+
+func runRestart(command string) (string, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("重启命令为空")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sslRestartTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, fields[0], fields[1:]...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("执行重启命令失败：%v，输出：%v", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
 }
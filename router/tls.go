@@ -0,0 +1,280 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：HTTPS服务和证书热加载
+//
+// 证书目录结构，默认目录 ssl：
+//
+//	ssl/fullchain.pem、ssl/privkey.pem             默认证书（/ssl.deploy 写入位置）
+//	ssl/{任意名称}/fullchain.pem、privkey.pem        其他域名证书，按证书中的域名通过SNI匹配
+//
+// 证书文件变更后自动重新加载，新连接使用新证书，已建立的连接不受影响；
+// 证书和私钥不匹配或格式错误时保留原证书继续使用。
+// 首次部署时目录中还没有证书，服务仍可启动：HTTP跳转端口在加载证书前直接提供服务，
+// 未配置跳转端口时主端口以HTTP提供服务，通过 /ssl.deploy 部署证书后重启生效。
+// *****************************************************************************
+
+package router
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+const (
+	certFile = "fullchain.pem"
+	keyFile  = "privkey.pem"
+)
+
+type TlsOption struct {
+	Dir           string        // 证书目录，默认 ssl
+	RedirectPort  int           // HTTP跳转HTTPS的端口，如80，为0时不开启
+	CheckInterval time.Duration // 检查证书文件变更的间隔，默认10秒
+}
+
+type certStore struct {
+	dir       string
+	mu        sync.RWMutex
+	pairs     map[string]*tls.Certificate // 证书目录 -> 证书
+	names     map[string]*tls.Certificate // 域名 -> 证书
+	def       *tls.Certificate
+	signature string // 文件修改时间和大小，变化时重新加载
+	checked   bool   // 是否已按 signature 加载过
+}
+
+// certs HTTPS开启后的证书，/ssl.deploy 写入后立即重新加载
+var certs *certStore
+
+func newCertStore(dir string) *certStore {
+	if dir == "" {
+		dir = "ssl"
+	}
+	store := &certStore{dir: filepath.Clean(dir), pairs: map[string]*tls.Certificate{}, names: map[string]*tls.Certificate{}}
+	if err := store.reload(); err != nil {
+		fmt.Println(fmt.Sprintf("+ HTTPS证书加载失败：%v", err))
+		vingo.LogError(fmt.Sprintf("[HTTPS]证书加载失败：%v", err))
+	}
+	return store
+}
+
+// loaded 是否已加载证书
+func (s *certStore) loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.def != nil
+}
+
+// pairDirs 包含证书文件的目录，默认目录在前
+func (s *certStore) pairDirs() []string {
+	var dirs []string
+	if isFile(filepath.Join(s.dir, certFile)) {
+		dirs = append(dirs, s.dir)
+	}
+	entries, _ := os.ReadDir(s.dir)
+	var subs []string
+	for _, entry := range entries {
+		if entry.IsDir() && isFile(filepath.Join(s.dir, entry.Name(), certFile)) {
+			subs = append(subs, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	sort.Strings(subs)
+	return append(dirs, subs...)
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func (s *certStore) currentSignature(dirs []string) string {
+	var b strings.Builder
+	for _, dir := range dirs {
+		for _, name := range []string{certFile, keyFile} {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				b.WriteString(fmt.Sprintf("%v:%d:%d;", filepath.Join(dir, name), info.ModTime().UnixNano(), info.Size()))
+			}
+		}
+	}
+	return b.String()
+}
+
+// reload 文件有变化时重新加载，单个证书加载失败时保留该证书的原内容
+func (s *certStore) reload() error {
+	failed := s.load()
+	dirs := make([]string, 0, len(failed))
+	for dir := range failed {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	errs := make([]error, 0, len(dirs))
+	for _, dir := range dirs {
+		errs = append(errs, failed[dir])
+	}
+	return errors.Join(errs...)
+}
+
+// load 重新加载证书，返回各目录的加载错误，没有可用证书时错误记录在证书根目录下
+func (s *certStore) load() map[string]error {
+	dirs := s.pairDirs()
+	signature := s.currentSignature(dirs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checked && signature == s.signature {
+		return nil
+	}
+
+	errs := map[string]error{}
+	pairs := map[string]*tls.Certificate{}
+	for _, dir := range dirs {
+		cert, err := loadPair(dir)
+		if err != nil {
+			errs[dir] = fmt.Errorf("%v：%w", dir, err)
+			if old, ok := s.pairs[dir]; ok {
+				pairs[dir] = old
+			}
+			continue
+		}
+		pairs[dir] = cert
+	}
+	if len(pairs) == 0 {
+		// 记录签名，证书文件变更前不再重复加载和报错
+		s.signature, s.checked = signature, true
+		if _, ok := errs[s.dir]; !ok {
+			errs[s.dir] = fmt.Errorf("目录[%v]中没有可用的证书", s.dir)
+		}
+		return errs
+	}
+
+	names := map[string]*tls.Certificate{}
+	var def *tls.Certificate
+	for _, dir := range dirs {
+		cert, ok := pairs[dir]
+		if !ok {
+			continue
+		}
+		if def == nil {
+			def = cert
+		}
+		for _, name := range certNames(cert.Leaf) {
+			if _, exists := names[name]; !exists {
+				names[name] = cert
+			}
+		}
+	}
+	// 存在失败时同样记录签名，文件再次变更后重新加载，避免重复报错
+	s.pairs, s.names, s.def, s.signature, s.checked = pairs, names, def, signature, true
+	return errs
+}
+
+func loadPair(dir string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, certFile), filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &cert, nil
+}
+
+func certNames(leaf *x509.Certificate) []string {
+	var names []string
+	for _, name := range leaf.DNSNames {
+		names = append(names, strings.ToLower(name))
+	}
+	if len(names) == 0 && leaf.Subject.CommonName != "" {
+		names = append(names, strings.ToLower(leaf.Subject.CommonName))
+	}
+	return names
+}
+
+// GetCertificate 按SNI域名选择证书，依次匹配完整域名、通配符域名，都不匹配时使用默认证书
+func (s *certStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if cert, ok := s.names[name]; ok {
+		return cert, nil
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		if cert, ok := s.names["*"+name[i:]]; ok {
+			return cert, nil
+		}
+	}
+	if s.def == nil {
+		return nil, errors.New("未加载证书")
+	}
+	return s.def, nil
+}
+
+// watch 定时检查证书文件变化
+func (s *certStore) watch(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.reloadAndLog()
+		}
+	}
+}
+
+func (s *certStore) reloadAndLog() {
+	if err := s.reload(); err != nil {
+		vingo.LogError(fmt.Sprintf("[HTTPS]证书加载失败，继续使用原证书：%v", err))
+	}
+}
+
+// reloadCertificate 证书部署后立即加载，只返回 dir 的错误，其他目录的证书错误由证书检查记录日志
+func reloadCertificate(dir string) error {
+	if _, err := loadPair(dir); err != nil {
+		return err
+	}
+	if certs == nil {
+		return nil
+	}
+	return certs.load()[filepath.Clean(dir)]
+}
+
+// redirectServer HTTP请求跳转到HTTPS，尚未加载证书时由 handler 直接处理，以便通过 /ssl.deploy 部署首个证书
+func redirectServer(port int, httpsPort int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr: fmt.Sprintf(":%d", port),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !certs.loaded() {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if httpsPort != 443 {
+				host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
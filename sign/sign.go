@@ -0,0 +1,307 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：开放接口签名
+//
+// 请求头：
+//
+//	X-App-Key         应用标识
+//	X-Timestamp       Unix时间戳（秒），与服务器时间相差不能超过 Window
+//	X-Nonce           随机串，Window 内不可重复使用
+//	X-Sign-Algorithm  HMAC-SHA256|HMAC-SM3，默认HMAC-SHA256
+//	X-Signature       签名，十六进制小写
+//
+// 签名原文，各项以换行分隔：
+//
+//	请求方法（大写）
+//	请求路径
+//	查询参数（按参数名、参数值排序后URL编码，以&连接）
+//	请求体摘要（与签名算法对应的SHA256或SM3，十六进制小写）
+//	X-Timestamp
+//	X-Nonce
+//	X-App-Key
+//
+// 服务端：r.Group("/open", sign.NewVerifier(sign.Option{Store: apps, Redis: redisApi}).Middleware())
+// 客户端：request.Option{Signer: &request.Signer{AppKey: "...", Secret: "..."}}
+// *****************************************************************************
+
+package sign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lgdzz/vingo-utils-v3/cryptor"
+	"github.com/lgdzz/vingo-utils-v3/redis"
+	"github.com/lgdzz/vingo-utils-v3/vingo"
+)
+
+const (
+	HmacSha256 = "HMAC-SHA256"
+	HmacSm3    = "HMAC-SM3"
+)
+
+const (
+	HeaderAppKey    = "X-App-Key"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderAlgorithm = "X-Sign-Algorithm"
+	HeaderSignature = "X-Signature"
+)
+
+type App struct {
+	Key      string
+	Secret   string
+	Disabled bool
+}
+
+// AppStore 应用查询，应用不存在时返回nil
+type AppStore interface {
+	GetApp(appKey string) (*App, error)
+}
+
+// MapStore 固定配置的应用，appKey -> secret
+type MapStore map[string]string
+
+func (s MapStore) GetApp(appKey string) (*App, error) {
+	secret, ok := s[appKey]
+	if !ok {
+		return nil, nil
+	}
+	return &App{Key: appKey, Secret: secret}, nil
+}
+
+func newHash(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "", HmacSha256:
+		return sha256.New, nil
+	case HmacSm3:
+		return cryptor.NewSm3, nil
+	}
+	return nil, fmt.Errorf("签名算法不支持：%v", algorithm)
+}
+
+// BodyHash 请求体摘要
+func BodyHash(algorithm string, body []byte) (string, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	d := h()
+	d.Write(body)
+	return hex.EncodeToString(d.Sum(nil)), nil
+}
+
+// CanonicalQuery 查询参数按参数名、参数值排序
+func CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var items []string
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			items = append(items, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(items, "&")
+}
+
+// Canonical 签名原文
+func Canonical(method string, path string, query url.Values, bodyHash string, timestamp string, nonce string, appKey string) string {
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{strings.ToUpper(method), path, CanonicalQuery(query), bodyHash, timestamp, nonce, appKey}, "\n")
+}
+
+// Signature 计算签名
+func Signature(algorithm string, secret string, canonical string) (string, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Sign 客户端为请求签名，设置签名请求头；请求体需可重复读取（http.NewRequest 传入的 bytes/strings 类型）
+func Sign(req *http.Request, appKey string, secret string, algorithm string) error {
+	if algorithm == "" {
+		algorithm = HmacSha256
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return errors.New("请求体不可重复读取，无法签名")
+		}
+		reader, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		body, err = io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	bodyHash, err := BodyHash(algorithm, body)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := strings.ReplaceAll(vingo.GetUUID(), "-", "")
+	signature, err := Signature(algorithm, secret, Canonical(req.Method, req.URL.Path, req.URL.Query(), bodyHash, timestamp, nonce, appKey))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderAppKey, appKey)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderAlgorithm, algorithm)
+	req.Header.Set(HeaderSignature, signature)
+	return nil
+}
+
+type Option struct {
+	Store      AppStore
+	Redis      *redis.Api    // 记录已使用的nonce，防止重放
+	Window     time.Duration // 时间戳允许的误差，默认5分钟
+	Algorithms []string      // 允许的签名算法，默认全部
+	MaxBody    int64         // 请求体最大字节数，默认10MB
+}
+
+type Verifier struct {
+	option     Option
+	algorithms map[string]bool
+}
+
+// NewVerifier 新建签名校验
+func NewVerifier(option Option) *Verifier {
+	if option.Store == nil {
+		panic("签名校验需要配置 Store")
+	}
+	if option.Redis == nil {
+		panic("签名校验需要配置redis")
+	}
+	if option.Window <= 0 {
+		option.Window = 5 * time.Minute
+	}
+	if option.MaxBody <= 0 {
+		option.MaxBody = 10 << 20
+	}
+	if len(option.Algorithms) == 0 {
+		option.Algorithms = []string{HmacSha256, HmacSm3}
+	}
+	algorithms := map[string]bool{}
+	for _, algorithm := range option.Algorithms {
+		if _, err := newHash(algorithm); err != nil {
+			panic(err.Error())
+		}
+		algorithms[algorithm] = true
+	}
+	return &Verifier{option: option, algorithms: algorithms}
+}
+
+// Verify 校验请求签名，校验后请求体可继续读取
+func (s *Verifier) Verify(req *http.Request) (*App, error) {
+	appKey := req.Header.Get(HeaderAppKey)
+	timestamp := req.Header.Get(HeaderTimestamp)
+	nonce := req.Header.Get(HeaderNonce)
+	signature := strings.ToLower(req.Header.Get(HeaderSignature))
+	algorithm := req.Header.Get(HeaderAlgorithm)
+	if algorithm == "" {
+		algorithm = HmacSha256
+	}
+	if appKey == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, errors.New("缺少签名参数")
+	}
+	if !s.algorithms[algorithm] {
+		return nil, fmt.Errorf("签名算法不支持：%v", algorithm)
+	}
+	if len(nonce) < 8 || len(nonce) > 64 {
+		return nil, errors.New("nonce长度应为8-64位")
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("时间戳格式错误")
+	}
+	if diff := time.Since(time.Unix(unix, 0)); diff > s.option.Window || diff < -s.option.Window {
+		return nil, errors.New("时间戳已过期")
+	}
+
+	app, err := s.option.Store.GetApp(appKey)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil || app.Disabled {
+		return nil, errors.New("应用不存在或已停用")
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(req.Body, s.option.MaxBody+1))
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > s.option.MaxBody {
+			return nil, errors.New("请求体过大")
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	bodyHash, _ := BodyHash(algorithm, body)
+	expected, _ := Signature(algorithm, app.Secret, Canonical(req.Method, req.URL.Path, req.URL.Query(), bodyHash, timestamp, nonce, appKey))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, errors.New("签名错误")
+	}
+
+	// 签名通过后再记录nonce，避免无效请求占用redis
+	key := s.option.Redis.Config.Prefix + "sign:nonce:" + appKey + ":" + nonce
+	ok, err := s.option.Redis.Client.SetNX(key, timestamp, 2*s.option.Window).Result()
+	if err != nil {
+		return nil, fmt.Errorf("nonce校验失败：%w", err)
+	}
+	if !ok {
+		return nil, errors.New("请求重复提交")
+	}
+	return app, nil
+}
+
+// Middleware 签名校验中间件，通过后可使用 sign.GetAppKey 获取应用标识
+func (s *Verifier) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		app, err := s.Verify(c.Request)
+		if err != nil {
+			vingo.LogError(fmt.Sprintf("[签名]校验失败，appKey：%v，路径：%v，原因：%v", c.GetHeader(HeaderAppKey), c.Request.URL.Path, err))
+			ctx := vingo.Context{Context: c}
+			ctx.Response(&vingo.ResponseData{Message: err.Error(), Status: http.StatusUnauthorized, Error: 1, ErrorType: "签名错误"})
+			c.Abort()
+			return
+		}
+		c.Set("appKey", app.Key)
+		c.Next()
+	}
+}
+
+// GetAppKey 签名校验通过的应用标识
+func GetAppKey(c *gin.Context) string {
+	return c.GetString("appKey")
+}
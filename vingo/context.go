@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"reflect"
 	"runtime/debug"
 	"strings"
 	"time"
@@ -35,6 +36,7 @@ func init() {
 	if Valid == nil {
 		Valid = validator.New()
 	}
+	initValidator()
 }
 
 // AddQuery 动态添加查询条件
@@ -87,57 +89,73 @@ func (c *Context) Response(d *ResponseData) {
 	uuid := c.GetString("requestUUID")
 
 	startTime := c.GetTime("requestStart")
-	method := c.Request.Method
-	url_ := c.UrlDecode()
-	uri_ := c.Request.RequestURI
-
-	userAgent := c.GetHeader("User-Agent")
-	clientIp := c.GetString("clientIp")
-	user := c.GetString("user")
-	body := c.GetString("requestBody")
-
-	errorType := d.ErrorType
-	errorMsg := ""
-	if d.Error == 1 {
-		errorMsg = d.Message
-	}
 
 	if !d.NoLog {
 		// 记录请求日志
+		logger := c.Logger()
+		url_ := c.UrlDecode()
+		if c.Request.Method != "GET" {
+			url_ = c.Request.RequestURI
+		}
+		errorMsg := ""
+		if d.Error == 1 {
+			errorMsg = d.Message
+		}
+		fields := []any{
+			"type", "request",
+			"method", c.Request.Method,
+			"url", url_,
+			"err", errorMsg,
+			"errType", d.ErrorType,
+			"userAgent", c.GetHeader("User-Agent"),
+			"clientIP", c.GetString("clientIp"),
+		}
+		if c.Request.Method != "GET" {
+			fields = append(fields, "body", jsonOrString(c.GetString("requestBody")))
+		}
+		// 在 recover 所在协程获取堆栈，包含异常发生的位置
+		var stack []byte
+		if d.ErrorType == "异常错误" {
+			stack = debug.Stack()
+		}
 		go func() {
 			defer ExceptionCatch("记录请求日志异常", false)
-			endTime := time.Now()
-			latency := endTime.Sub(startTime)
+			latency := time.Since(startTime)
 			millisecond := float64(latency.Nanoseconds()) / float64(time.Millisecond)
-			duration := fmt.Sprintf("%.3fms", millisecond)
-			if millisecond > 300 {
-				duration += ":慢接口"
-			}
-
-			if method == "GET" {
-				LogRequest(duration, fmt.Sprintf("{\"uuid\":\"%v\",\"method\":\"%v\",\"url\":\"%v\",\"err\":\"%v\",\"errType\":\"%v\",\"userAgent\":\"%v\",\"clientIP\":\"%v\",\"user\":\"%v\"}", uuid, method, url_, errorMsg, errorType, userAgent, clientIp, user))
-			} else {
-				bodyVal := body
-				if bodyVal == "" {
-					bodyVal = "\"\""
-				}
+			fields = append(fields, "duration", fmt.Sprintf("%.3fms", millisecond), "slow", millisecond > 300)
+			logger.Info("request", fields...)
 
-				LogRequest(duration, fmt.Sprintf("{\"uuid\":\"%v\",\"method\":\"%v\",\"url\":\"%v\",\"body\":%v,\"err\":\"%v\",\"errType\":\"%v\",\"userAgent\":\"%v\",\"clientIP\":\"%v\",\"user\":\"%v\"}", uuid, method, uri_, bodyVal, errorMsg, errorType, userAgent, clientIp, user))
-			}
-
-			if errorType == "异常错误" {
-				LogError(string(debug.Stack()))
+			if stack != nil {
+				logger.Error("异常错误", "stack", string(stack))
 			}
 		}()
 	}
 
-	c.JSON(d.Status, gin.H{
+	data := c.Mask(d.Data)
+	// 记录最终响应，供幂等等中间件在处理完成后读取
+	c.Set("responseData", &ResponseData{Status: d.Status, Error: d.Error, ErrorType: d.ErrorType, Message: d.Message, Data: data, NoLog: d.NoLog, Errors: d.Errors})
+
+	result := gin.H{
 		"uuid":      uuid,
 		"error":     d.Error,
 		"message":   d.Message,
-		"data":      d.Data,
+		"data":      data,
 		"timestamp": time.Now().Unix(),
-	})
+	}
+	if len(d.Errors) > 0 {
+		result["errors"] = d.Errors
+	}
+	c.JSON(d.Status, result)
+}
+
+// GetResponseData 获取已写入的响应，未调用 Response 时返回nil
+func (c *Context) GetResponseData() *ResponseData {
+	if value, ok := c.Get("responseData"); ok {
+		if d, ok := value.(*ResponseData); ok {
+			return d
+		}
+	}
+	return nil
 }
 
 // ResponseBody 请求成功，带data数据
@@ -159,7 +177,7 @@ func (c *Context) ResponseBase64(data ...any) {
 	if len(data) == 0 {
 		c.Response(&ResponseData{})
 	} else {
-		byteData, err := json.Marshal(data[0])
+		byteData, err := json.Marshal(c.Mask(data[0]))
 		if err != nil {
 			panic(fmt.Sprintf("Error marshaling data: %v", err))
 		}
@@ -190,7 +208,11 @@ func AllowCrossDomain(r *gin.Engine) {
 }
 
 // ApiAddress 输出接口地址
-func ApiAddress(port int) {
+func ApiAddress(port int, scheme ...string) {
+	protocol := "http"
+	if len(scheme) > 0 && scheme[0] != "" {
+		protocol = scheme[0]
+	}
 	addr, err := net.InterfaceAddrs()
 	if err != nil {
 		fmt.Println(err)
@@ -200,7 +222,7 @@ func ApiAddress(port int) {
 	for _, item := range addr {
 		if ipNet, ok := item.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
 			if ipNet.IP.To4() != nil {
-				fmt.Println(fmt.Sprintf("+ 接口地址：http://%v:%d", ipNet.IP.String(), port))
+				fmt.Println(fmt.Sprintf("+ 接口地址：%v://%v:%d", protocol, ipNet.IP.String(), port))
 			}
 		}
 	}
@@ -387,15 +409,16 @@ func RoutesGet(g *gin.RouterGroup, path string, handler func(*Context)) {
 	})
 }
 
-// RoutesPost 注册post路由
-func RoutesPost(g *gin.RouterGroup, path string, handler func(*Context)) {
-	g.POST(path, func(c *gin.Context) {
+// RoutesPost 注册post路由，middles 在 handler 之前执行，如幂等校验
+func RoutesPost(g *gin.RouterGroup, path string, handler func(*Context), middles ...gin.HandlerFunc) {
+	handlers := append(append([]gin.HandlerFunc{}, middles...), func(c *gin.Context) {
 		context := &Context{Context: c}
 		handler(context)
 		if ApiOplog.Enable {
 			go ApiOplog.Write(context)
 		}
 	})
+	g.POST(path, handlers...)
 }
 
 // RoutesPut 注册put路由
@@ -432,13 +455,12 @@ func RoutesDelete(g *gin.RouterGroup, path string, handler func(*Context)) {
 func GetRequestBody[T any](c *Context, valid ...bool) T {
 	var body T
 	if err := c.ShouldBindJSON(&body); err != nil {
-		panic(err.Error())
+		c.validationPanic(err, reflect.TypeOf(body))
 	}
 
 	if len(valid) > 0 && valid[0] {
 		if err := Valid.Struct(body); err != nil {
-			// handle validation error
-			panic(err)
+			c.validationPanic(err, reflect.TypeOf(body))
 		}
 	}
 
@@ -475,18 +497,19 @@ func GetRequestBody[T any](c *Context, valid ...bool) T {
 func GetRequestQuery[T any](c *Context) T {
 	var query T
 	if err := c.ShouldBindQuery(&query); err != nil {
-		panic(err.Error())
+		c.validationPanic(err, reflect.TypeOf(query))
 	}
 	return query
 }
 
 type ResponseData struct {
-	Status    int    // 状态
-	Error     int    // 0-无错误|1-有错误
-	ErrorType string // 错误类型
-	Message   string // 消息
-	Data      any    // 返回数据内容
-	NoLog     bool   // true时不记录日志
+	Status    int          // 状态
+	Error     int          // 0-无错误|1-有错误
+	ErrorType string       // 错误类型
+	Message   string       // 消息
+	Data      any          // 返回数据内容
+	NoLog     bool         // true时不记录日志
+	Errors    []FieldError // 参数校验错误，按字段返回
 }
 
 type Oplog struct {
@@ -2,19 +2,24 @@ package vingo
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
 const (
-	bufferSize    = 1000
-	flushInterval = 5 * time.Second
-	maxTokenSize  = 1024 * 1024 * 10
+	bufferSize      = 1000
+	flushInterval   = 5 * time.Second
+	cleanupInterval = time.Hour
+	maxTokenSize    = 1024 * 1024 * 10
 )
 
 var (
@@ -22,177 +27,445 @@ var (
 	buffer     []string
 	file       *os.File
 	filename   string
+	fileDay    string // 当前文件日期 20060102
+	fileIndex  int    // 当前文件序号，0 为 log_20060102.log，之后为 log_20060102.1.log
+	fileSize   int64
 	flushTimer *time.Timer
-	maxAge     = 30 * 24 * time.Hour
+	cleanupCh  = make(chan struct{}, 1)
+	logOption  = LogOption{MaxDay: 30, MaxSize: 100}
 )
 
 var dstDir = "runtime/logs"
 var Enable = true
 
+// logFileRegex 日志文件名 log_20060102.log、log_20060102.1.log 及压缩后的 .gz，清理和查询只处理该格式的文件
+var logFileRegex = regexp.MustCompile(`^log_(\d{8})(?:\.(\d+))?\.log(\.gz)?$`)
+
+type LogOption struct {
+	MaxDay          int  // 保留天数，默认30
+	MaxSize         int  // 单个文件大小上限（MB），超过后切分为 log_20060102.1.log，默认100
+	MaxTotal        int  // 日志目录总大小上限（MB），超过时从最早的文件开始删除，0 为不限制
+	DisableCompress bool // 不压缩已切分的文件，默认压缩为 .gz
+}
+
 func InitLogService(maxDay *int) {
+	option := LogOption{}
+	if maxDay != nil {
+		option.MaxDay = *maxDay
+	}
+	InitLogServiceWithOption(option)
+}
+
+// InitLogServiceWithOption 启动日志文件服务，Enable 为 false 时不创建日志文件
+func InitLogServiceWithOption(option LogOption) {
+	if option.MaxDay <= 0 {
+		option.MaxDay = 30
+	}
+	if option.MaxSize <= 0 {
+		option.MaxSize = 100
+	}
+	registerLogShutdown()
+	if !Enable {
+		return
+	}
+
 	if _, err := os.Stat(dstDir); os.IsNotExist(err) {
 		if err = os.MkdirAll(dstDir, 0755); err != nil {
 			panic(err.Error())
 		}
 	}
 
-	if maxDay != nil && *maxDay > 0 {
-		maxAge = time.Duration(*maxDay) * 24 * time.Hour
-	}
-
-	filename = generateFilename()
-	err := createLogFile()
-	if err != nil {
+	mu.Lock()
+	logOption = option
+	if err := openLogFile(time.Now().Local()); err != nil {
+		mu.Unlock()
 		panic(err)
 	}
 	flushTimer = time.AfterFunc(flushInterval, flush)
-	go writeLoop()
-	go deleteOldLogs()
+	mu.Unlock()
+
+	go cleanupLoop()
 }
 
-func generateFilename() string {
-	now := time.Now().Local()
-	return fmt.Sprintf("%v/log_%04d%02d%02d.log", dstDir, now.Year(), now.Month(), now.Day())
+// FlushLog 将缓冲的日志立即写入文件和其他输出，停机前调用避免丢失日志
+func FlushLog() {
+	flush()
+	flushLogSinks()
 }
 
-func createLogFile() error {
-	var err error
-	file, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if os.IsNotExist(err) {
-		// 如果文件不存在则创建新文件
-		file, err = os.Create(filename)
+// closeLogFile 停机时写入剩余日志后关闭文件，之后的日志不再写入文件
+func closeLogFile() {
+	mu.Lock()
+	defer mu.Unlock()
+	if flushTimer != nil {
+		flushTimer.Stop()
+		flushTimer = nil
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "error closing log file:", err)
+		}
+		file = nil
+	}
+}
+
+func logFilename(day string, index int) string {
+	if index == 0 {
+		return filepath.Join(dstDir, fmt.Sprintf("log_%v.log", day))
+	}
+	return filepath.Join(dstDir, fmt.Sprintf("log_%v.%d.log", day, index))
+}
+
+// openLogFile 打开当天序号最大的文件，已压缩或已达到大小上限时使用下一个序号
+func openLogFile(now time.Time) error {
+	day := now.Format("20060102")
+	index := -1
+	compressed := false
+	entries, _ := os.ReadDir(dstDir)
+	for _, entry := range entries {
+		match := logFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil || match[1] != day {
+			continue
+		}
+		i, _ := strconv.Atoi(match[2])
+		if i > index || (i == index && match[3] != "") {
+			index = i
+			compressed = match[3] != ""
+		}
+	}
+	if index < 0 {
+		index = 0
+	} else if compressed {
+		index++
+	} else if info, err := os.Stat(logFilename(day, index)); err == nil && info.Size() >= logMaxSize() {
+		index++
+	}
+	return openLogFileIndex(day, index)
+}
+
+func openLogFileIndex(day string, index int) error {
+	name := logFilename(day, index)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	file, filename, fileDay, fileIndex, fileSize = f, name, day, index, info.Size()
+	return nil
+}
+
+// rotateLogFile 关闭当前文件并打开新文件，由清理任务压缩已关闭的文件
+func rotateLogFile(open func() error) error {
+	if file != nil {
+		if err := file.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "error closing log file:", err)
+		}
+		file = nil
+	}
+	if err := open(); err != nil {
+		return err
 	}
-	return err
+	select {
+	case cleanupCh <- struct{}{}:
+	default:
+	}
+	return nil
 }
 
-func isFileExist(filename string) bool {
-	_, err := os.Stat(filename)
-	return err == nil
+func logMaxSize() int64 {
+	return int64(logOption.MaxSize) * 1024 * 1024
 }
 
 func flush() {
 	mu.Lock()
 	defer mu.Unlock()
 
-	if len(buffer) == 0 {
-		flushTimer.Reset(flushInterval)
+	if flushTimer == nil {
+		// 服务未启动或已停止
 		return
 	}
+	defer flushTimer.Reset(flushInterval)
 
-	// 获取日志文件名称
-	filename = generateFilename()
+	if len(buffer) == 0 {
+		return
+	}
 
-	// 判断文件是否存在
-	if file == nil {
-		var err error
-		file, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "error opening log file:", err)
-			return
-		}
-	} else if !isSameFile(file.Name(), filename) {
-		// 如果文件不同则关闭旧文件，创建新文件
-		err := file.Close()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "error closing log file:", err)
-		}
-		file, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
+	// 跨天后切换到新日期的文件
+	if now := time.Now().Local(); file == nil || now.Format("20060102") != fileDay {
+		if err := rotateLogFile(func() error { return openLogFile(now) }); err != nil {
 			fmt.Fprintln(os.Stderr, "error opening log file:", err)
 			return
 		}
 	}
 
+	maxSize := logMaxSize()
 	for _, message := range buffer {
-		fmt.Fprintln(file, message)
+		if fileSize > 0 && fileSize+int64(len(message))+1 > maxSize {
+			if err := file.Sync(); err != nil {
+				fmt.Fprintln(os.Stderr, "error syncing log file:", err)
+			}
+			if err := rotateLogFile(func() error { return openLogFileIndex(fileDay, fileIndex+1) }); err != nil {
+				fmt.Fprintln(os.Stderr, "error opening log file:", err)
+				return
+			}
+		}
+		n, err := fmt.Fprintln(file, message)
+		fileSize += int64(n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error writing log file:", err)
+		}
 	}
 	buffer = buffer[:0]
-	err := file.Sync()
-	if err != nil {
+	if err := file.Sync(); err != nil {
 		fmt.Fprintln(os.Stderr, "error syncing log file:", err)
 	}
-	flushTimer.Reset(flushInterval)
 }
 
-func isSameFile(file1, file2 string) bool {
-	info1, err := os.Stat(file1)
-	if err != nil {
+func writeLog(message string) {
+	if !Enable {
+		return
+	}
+	mu.Lock()
+	buffer = append(buffer, message)
+	if len(buffer) < bufferSize {
+		mu.Unlock()
+		return
+	}
+	if flushTimer == nil {
+		// 服务启动前只保留最近的日志，启动后写入文件
+		buffer = buffer[len(buffer)-bufferSize+1:]
+		mu.Unlock()
+		return
+	}
+	mu.Unlock()
+	flush()
+}
+
+type logFileInfo struct {
+	path       string
+	day        string
+	index      int
+	compressed bool
+	size       int64
+	modTime    time.Time
+}
+
+// inUse 是否为当前写入的文件或之后切分出的文件
+// 清理期间日志可能已切分，每次压缩或删除前在 mu 下重新判断
+func (s logFileInfo) inUse() bool {
+	if s.compressed {
 		return false
 	}
-	info2, err := os.Stat(file2)
+	mu.Lock()
+	defer mu.Unlock()
+	if s.path == filename {
+		return true
+	}
+	return fileDay != "" && (s.day > fileDay || (s.day == fileDay && s.index >= fileIndex))
+}
+
+// listLogFiles 日志目录下的日志文件，按日期和序号排序
+func listLogFiles() ([]logFileInfo, error) {
+	entries, err := os.ReadDir(dstDir)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return os.SameFile(info1, info2)
+	var files []logFileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := logFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		index, _ := strconv.Atoi(match[2])
+		files = append(files, logFileInfo{
+			path:       filepath.Join(dstDir, entry.Name()),
+			day:        match[1],
+			index:      index,
+			compressed: match[3] != "",
+			size:       info.Size(),
+			modTime:    info.ModTime(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].day != files[j].day {
+			return files[i].day < files[j].day
+		}
+		if files[i].index != files[j].index {
+			return files[i].index < files[j].index
+		}
+		return !files[i].compressed && files[j].compressed
+	})
+	return files, nil
 }
 
-func writeLoop() {
+func cleanupLoop() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
 	for {
+		cleanupLogs()
 		select {
-		case <-flushTimer.C:
-			flush()
+		case <-ticker.C:
+		case <-cleanupCh:
 		}
 	}
 }
 
-func writeLog(message string) {
-	if !Enable {
-		return
-	}
+// cleanupLogs 压缩已关闭的文件，删除过期文件，超过总大小上限时从最早的文件开始删除，只处理日志目录下的日志文件
+func cleanupLogs() {
+	defer ExceptionCatch("清理日志文件异常", false)
+
 	mu.Lock()
-	buffer = append(buffer, message)
-	if len(buffer) >= bufferSize {
-		mu.Unlock()
-		flush()
+	option := logOption
+	mu.Unlock()
+
+	files, err := listLogFiles()
+	if err != nil {
+		LogError(fmt.Sprintf("error reading log directory: %v", err.Error()))
 		return
 	}
-	mu.Unlock()
-}
 
-func deleteOldLogs() {
-	for {
-		filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+	var (
+		kept  []logFileInfo
+		total int64
+	)
+	for _, item := range files {
+		if item.inUse() {
+			kept = append(kept, item)
+			total += item.size
+			continue
+		}
+		if time.Since(item.modTime) > time.Duration(option.MaxDay)*24*time.Hour {
+			removeLogFile(item.path)
+			continue
+		}
+		if !item.compressed && !option.DisableCompress {
+			size, err := compressLogFile(item.path)
 			if err != nil {
-				LogError(fmt.Sprintf("error walking directory: %v", err.Error()))
-				return err
-			}
-			if !info.IsDir() && isLogFile(path) && isOldLog(info) {
-				err := os.Remove(path)
-				if err != nil {
-					LogError(fmt.Sprintf("error removing old log file: %v", err.Error()))
-				} else {
-					LogInfo(fmt.Sprintf("Removed old log file: %v", path))
-				}
+				LogError(fmt.Sprintf("error compressing log file: %v", err.Error()))
+			} else {
+				item.path += ".gz"
+				item.compressed = true
+				item.size = size
 			}
-			return nil
-		})
-		time.Sleep(24 * time.Hour)
+		}
+		kept = append(kept, item)
+		total += item.size
+	}
+
+	if option.MaxTotal <= 0 {
+		return
+	}
+	quota := int64(option.MaxTotal) * 1024 * 1024
+	for _, item := range kept {
+		if total <= quota {
+			break
+		}
+		if item.inUse() {
+			continue
+		}
+		if removeLogFile(item.path) {
+			total -= item.size
+		}
 	}
 }
 
-func isLogFile(path string) bool {
-	ext := filepath.Ext(path)
-	return ext == ".log"
+func removeLogFile(path string) bool {
+	if err := os.Remove(path); err != nil {
+		LogError(fmt.Sprintf("error removing old log file: %v", err.Error()))
+		return false
+	}
+	LogInfo(fmt.Sprintf("Removed old log file: %v", path))
+	return true
 }
 
-func isOldLog(info os.FileInfo) bool {
-	return time.Since(info.ModTime()) > maxAge
+// compressLogFile 压缩为 path.gz 后删除原文件，返回压缩后的大小
+func compressLogFile(path string) (int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	if err = os.Rename(tmp, path+".gz"); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	// 保留原修改时间，过期判断以最后写入时间为准
+	_ = os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	_ = src.Close()
+	if err = os.Remove(path); err != nil {
+		return 0, err
+	}
+	gzInfo, err := os.Stat(path + ".gz")
+	if err != nil {
+		return 0, err
+	}
+	return gzInfo.Size(), nil
 }
 
+// defaultLogger 无附加字段，供以下函数使用
+var defaultLogger = &Logger{}
+
 func Log(message string) {
-	go writeLog(fmt.Sprintf("[%s] %s", time.Now().Local().Format("2006-01-02 15:04:05"), message))
+	defaultLogger.Info(message)
 }
 
+// LogRequest 请求日志，message 为 JSON 时作为对象输出
 func LogRequest(t string, message string) {
-	writeLog(fmt.Sprintf("[%s][REQUEST][%v] %s", time.Now().Format("2006-01-02 15:04:05"), t, strings.ReplaceAll(message, "\n", "@n@n@n")))
+	defaultLogger.Info("request", "type", "request", "duration", t, "request", jsonOrString(message))
+}
+
+func LogDebug(message string) {
+	defaultLogger.Debug(message)
 }
 
 func LogInfo(message string) {
-	go writeLog(fmt.Sprintf("[%s][INFO][-] %s", time.Now().Format("2006-01-02 15:04:05"), strings.ReplaceAll(message, "\n", "@n@n@n")))
+	defaultLogger.Info(message)
+}
+
+func LogWarn(message string) {
+	defaultLogger.Warn(message)
 }
 
 func LogError(message string) {
-	go writeLog(fmt.Sprintf("[%s][ERROR][-] %s", time.Now().Format("2006-01-02 15:04:05"), strings.ReplaceAll(message, "\n", "@n@n@n")))
+	defaultLogger.Error(message)
 }
 
 type LogFileItem struct {
@@ -200,45 +473,45 @@ type LogFileItem struct {
 	Size   int64  `json:"size"`
 }
 
-// GetLogFiles 获取日志文件列表
+// GetLogFiles 获取日志文件列表，包括已压缩的 .gz 文件
 func GetLogFiles() []LogFileItem {
-	var files []LogFileItem
-
-	// Walk through the directory to find log files
-	err := filepath.Walk(dstDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories
-		if info.IsDir() {
+	files, err := listLogFiles()
+	if err != nil {
+		if os.IsNotExist(err) {
 			return nil
 		}
-
-		// Check if the file has a .log extension
-		if strings.HasSuffix(info.Name(), ".log") {
-			files = append(files, LogFileItem{
-				Source: path,
-				Size:   info.Size(),
-			})
-		}
-
-		return nil
-	})
-
-	if err != nil {
 		panic(err)
 	}
 
-	return files
+	var items []LogFileItem
+	for _, item := range files {
+		items = append(items, LogFileItem{
+			Source: item.path,
+			Size:   item.size,
+		})
+	}
+	return items
 }
 
 var (
 	timeLayout = "2006-01-02 15:04:05"
-	timeRegex  = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\]`)
+	// 兼容旧格式 [2006-01-02 15:04:05] 和 JSON 格式 {"time":"2006-01-02 15:04:05"
+	timeRegex = regexp.MustCompile(`^(?:\[|\{"time":")(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
 )
 
-// FindLogs 查询日志
+// logSource 查询的文件只能是日志目录下的日志文件
+func logSource(source string) string {
+	name := filepath.Base(source)
+	if !logFileRegex.MatchString(name) {
+		panic("日志文件不存在")
+	}
+	if dir := filepath.Dir(filepath.Clean(source)); dir != "." && dir != filepath.Clean(dstDir) {
+		panic("日志文件不存在")
+	}
+	return filepath.Join(dstDir, name)
+}
+
+// FindLogs 查询日志，支持 .gz 压缩文件
 func FindLogs(source string, keyword string, startTime string, endTime string) []string {
 	// keyword 正则
 	regex := fmt.Sprintf(`.*%s`, keyword)
@@ -267,7 +540,17 @@ func FindLogs(source string, keyword string, startTime string, endTime string) [
 		}
 		defer file.Close()
 
-		scanner := bufio.NewScanner(file)
+		var reader io.Reader = file
+		if strings.HasSuffix(path, ".gz") {
+			zr, err := gzip.NewReader(file)
+			if err != nil {
+				return err
+			}
+			defer zr.Close()
+			reader = zr
+		}
+
+		scanner := bufio.NewScanner(reader)
 		scanner.Buffer(make([]byte, maxTokenSize), maxTokenSize)
 
 		for scanner.Scan() {
@@ -298,17 +581,17 @@ func FindLogs(source string, keyword string, startTime string, endTime string) [
 	}
 
 	if source == "" {
-		err := filepath.Walk(dstDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return err
-			}
-			return readFile(path)
-		})
-		if err != nil {
+		files, err := listLogFiles()
+		if err != nil && !os.IsNotExist(err) {
 			panic(err)
 		}
+		for _, item := range files {
+			if err = readFile(item.path); err != nil {
+				panic(err)
+			}
+		}
 	} else {
-		if err := readFile(source); err != nil {
+		if err := readFile(logSource(source)); err != nil {
 			panic(err)
 		}
 	}
@@ -0,0 +1,156 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：日志输出
+// *****************************************************************************
+
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSink 写入日志文件，按天和大小切分，需先调用 InitLogService
+var FileSink LogSink = fileSink{}
+
+type fileSink struct{}
+
+func (fileSink) Write(line []byte) error {
+	writeLog(string(line))
+	return nil
+}
+
+// StdoutSink 输出到控制台
+var StdoutSink LogSink = &stdoutSink{}
+
+type stdoutSink struct {
+	mu sync.Mutex
+}
+
+func (s *stdoutSink) Write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintln(os.Stdout, string(line))
+	return err
+}
+
+// HttpSink 批量 POST 到日志收集接口，请求体为多行 JSON（application/x-ndjson）
+// 队列满时丢弃日志，避免日志服务异常影响业务
+type HttpSink struct {
+	Url       string
+	Header    map[string]string
+	BatchSize int           // 每批最多条数，默认100
+	Interval  time.Duration // 最长发送间隔，默认2秒
+	client    *http.Client
+	ch        chan []byte
+	flushCh   chan chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewHttpSink 创建 HTTP 日志输出
+func NewHttpSink(url string, header map[string]string) *HttpSink {
+	s := &HttpSink{
+		Url:       url,
+		Header:    header,
+		BatchSize: 100,
+		Interval:  2 * time.Second,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		ch:        make(chan []byte, 10000),
+		flushCh:   make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *HttpSink) Write(line []byte) error {
+	select {
+	case s.ch <- line:
+		return nil
+	default:
+		return fmt.Errorf("http log sink queue is full, log dropped")
+	}
+}
+
+// Flush 发送队列中的日志
+func (s *HttpSink) Flush() error {
+	ack := make(chan struct{})
+	select {
+	case s.flushCh <- ack:
+		<-ack
+	case <-s.done:
+	}
+	return nil
+}
+
+// Close 发送剩余日志后停止
+func (s *HttpSink) Close() error {
+	s.closeOnce.Do(func() {
+		_ = s.Flush()
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *HttpSink) loop() {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	batch := make([][]byte, 0, s.BatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.post(batch); err != nil {
+			fmt.Fprintln(os.Stderr, "error posting logs:", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case line := <-s.ch:
+			batch = append(batch, line)
+			if len(batch) >= s.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-s.flushCh:
+			for len(s.ch) > 0 {
+				batch = append(batch, <-s.ch)
+				if len(batch) >= s.BatchSize {
+					send()
+				}
+			}
+			send()
+			close(ack)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *HttpSink) post(lines [][]byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.Url, bytes.NewReader(append(bytes.Join(lines, []byte("\n")), '\n')))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for key, value := range s.Header {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("log server responded %v", resp.Status)
+	}
+	return nil
+}
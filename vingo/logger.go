@@ -0,0 +1,256 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：结构化日志
+//
+// 每条日志输出为一行 JSON，依次为 time、level、msg 和附加字段，写入已配置的输出（默认日志文件）：
+//
+//	vingo.NewLogger("module", "order").Info("订单已创建", "orderId", order.Id, "amount", order.Amount)
+//	c.Logger().Error("支付回调失败", "err", err) // 自动附加 uuid、userId、user
+//
+// 输出：{"time":"2026-10-19 12:00:00","level":"INFO","msg":"订单已创建","module":"order","orderId":1,"amount":"9.90"}
+// *****************************************************************************
+
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// LogMinLevel 低于该级别的日志不输出
+var LogMinLevel = LogLevelInfo
+
+// LogSink 日志输出，line 为一行 JSON（不含换行），Write 在写日志的协程中同步调用，耗时的输出需自行异步处理
+// 实现 Flush() error 或 Close() error 时，停机时依次调用
+type LogSink interface {
+	Write(line []byte) error
+}
+
+var (
+	sinkMu   sync.RWMutex
+	logSinks = []LogSink{FileSink}
+
+	sinkShutdownOnce sync.Once
+)
+
+// SetLogSinks 替换日志输出，传入 FileSink 保留日志文件
+//
+//	vingo.SetLogSinks(vingo.FileSink, vingo.StdoutSink, kafka.NewLogSink(config))
+func SetLogSinks(sinks ...LogSink) {
+	sinkMu.Lock()
+	logSinks = append([]LogSink{}, sinks...)
+	sinkMu.Unlock()
+	registerLogShutdown()
+}
+
+// AddLogSink 增加日志输出
+func AddLogSink(sink LogSink) {
+	sinkMu.Lock()
+	logSinks = append(logSinks, sink)
+	sinkMu.Unlock()
+	registerLogShutdown()
+}
+
+// registerLogShutdown 停机时写入缓冲的日志并关闭文件和其他输出，只注册一次
+func registerLogShutdown() {
+	sinkShutdownOnce.Do(func() {
+		RegisterShutdown("日志", ShutdownLog, func(ctx context.Context) error {
+			FlushLog()
+			closeLogFile()
+			closeLogSinks()
+			return nil
+		})
+	})
+}
+
+// flushLogSinks 写入各输出缓冲的日志
+func flushLogSinks() {
+	sinkMu.RLock()
+	sinks := append([]LogSink{}, logSinks...)
+	sinkMu.RUnlock()
+	for _, sink := range sinks {
+		if s, ok := sink.(interface{ Flush() error }); ok {
+			if err := s.Flush(); err != nil {
+				fmt.Fprintln(os.Stderr, "error flushing log sink:", err)
+			}
+		}
+	}
+}
+
+func closeLogSinks() {
+	sinkMu.RLock()
+	sinks := append([]LogSink{}, logSinks...)
+	sinkMu.RUnlock()
+	for _, sink := range sinks {
+		if s, ok := sink.(interface{ Close() error }); ok {
+			if err := s.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "error closing log sink:", err)
+			}
+		}
+	}
+}
+
+// Logger 带附加字段的日志，字段以键值对传入
+type Logger struct {
+	fields []any
+}
+
+// NewLogger 创建日志，kv 为每条日志附加的字段
+func NewLogger(kv ...any) *Logger {
+	return &Logger{fields: kv}
+}
+
+// With 返回附加了字段的新日志
+func (l *Logger) With(kv ...any) *Logger {
+	fields := make([]any, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	return &Logger{fields: fields}
+}
+
+func (l *Logger) Debug(msg string, kv ...any) {
+	l.log(LogLevelDebug, msg, kv)
+}
+
+func (l *Logger) Info(msg string, kv ...any) {
+	l.log(LogLevelInfo, msg, kv)
+}
+
+func (l *Logger) Warn(msg string, kv ...any) {
+	l.log(LogLevelWarn, msg, kv)
+}
+
+func (l *Logger) Error(msg string, kv ...any) {
+	l.log(LogLevelError, msg, kv)
+}
+
+func (l *Logger) log(level LogLevel, msg string, kv []any) {
+	if !Enable || level < LogMinLevel {
+		return
+	}
+	line := encodeLogLine(time.Now().Local(), level, msg, l.fields, kv)
+
+	sinkMu.RLock()
+	sinks := logSinks
+	sinkMu.RUnlock()
+	for _, sink := range sinks {
+		if err := sink.Write(line); err != nil {
+			fmt.Fprintln(os.Stderr, "error writing log:", err)
+		}
+	}
+}
+
+// encodeLogLine 按 time、level、msg、附加字段的顺序输出 JSON，重复的字段取后面的值
+func encodeLogLine(t time.Time, level LogLevel, msg string, groups ...[]any) []byte {
+	var (
+		keys   = []string{"time", "level", "msg"}
+		values = map[string]any{"time": t.Format(timeLayout), "level": level.String(), "msg": msg}
+	)
+	for _, kv := range groups {
+		for i := 0; i < len(kv); i += 2 {
+			key, ok := kv[i].(string)
+			if !ok {
+				key = fmt.Sprint(kv[i])
+			}
+			var value any = "!MISSING"
+			if i+1 < len(kv) {
+				value = kv[i+1]
+			}
+			if _, exists := values[key]; !exists {
+				keys = append(keys, key)
+			}
+			values[key] = value
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := marshalLogValue(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(encodeLogValue(values[key]))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+func encodeLogValue(value any) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case fmt.Stringer:
+		if _, ok := value.(json.Marshaler); !ok {
+			value = v.String()
+		}
+	}
+	data, err := marshalLogValue(value)
+	if err != nil {
+		data, _ = marshalLogValue(fmt.Sprint(value))
+	}
+	return data
+}
+
+// marshalLogValue 不转义 &、<、>，便于按原文检索
+func marshalLogValue(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// jsonOrString 合法的 JSON 原样输出，否则作为字符串
+func jsonOrString(value string) any {
+	if value = strings.TrimSpace(value); value != "" && json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	return value
+}
+
+// Logger 返回附加了请求标识和用户信息的日志
+func (c *Context) Logger() *Logger {
+	fields := []any{"uuid", c.GetString("requestUUID")}
+	if userId := c.GetUserId(); userId > 0 {
+		fields = append(fields, "userId", userId)
+	}
+	if user := c.GetString("user"); user != "" {
+		fields = append(fields, "user", user)
+	}
+	return NewLogger(fields...)
+}
@@ -0,0 +1,264 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：响应数据脱敏
+//
+// Response 输出前根据字段 mask 标签脱敏，支持嵌套结构体、指针、切片、map 以及 PageResult.Items 等 any 字段：
+//
+//	Phone    ctype.Phone      `json:"phone" mask:"phone"`                 // 133****8888
+//	IdCard   ctype.IdCard     `json:"idCard" mask:"idcard" unmask:"hr"`   // 410***********1234，hr 角色可见明文
+//	Realname string           `json:"realname" mask:"name"`               // 张**
+//	BankNo   ctype.Ciphertext `json:"bankNo" mask:"bankcard"`             // 6222***********1234
+//	Email    string           `json:"email" mask:"email"`                 // z***@qq.com
+//	Plate    string           `json:"plate" mask:"custom=2,1"`            // 保留前2位后1位
+//	Other    string           `json:"other" mask:"other"`                 // RegisterMasker 注册的脱敏方式
+//
+// 拥有 UnmaskRoleTags 或字段 unmask 标签中角色标识的用户可见明文，每次明文访问都会记录日志。
+// 脱敏作用于数据副本，不会修改原数据。
+// *****************************************************************************
+
+package vingo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UnmaskRoleTags 可查看所有脱敏字段明文的角色标识
+var UnmaskRoleTags []string
+
+var maskers = sync.Map{}
+
+// 类型是否包含需要脱敏的字段
+var maskTypes = sync.Map{}
+
+func init() {
+	RegisterMasker("phone", func(value string) string {
+		return MaskKeep(value, 3, 4)
+	})
+	RegisterMasker("idcard", func(value string) string {
+		return MaskKeep(value, 3, 4)
+	})
+	RegisterMasker("name", func(value string) string {
+		return MaskKeep(value, 1, 0)
+	})
+	RegisterMasker("bankcard", func(value string) string {
+		return MaskKeep(value, 4, 4)
+	})
+	RegisterMasker("email", func(value string) string {
+		name, domain, ok := strings.Cut(value, "@")
+		if !ok {
+			return MaskKeep(value, 1, 0)
+		}
+		return MaskKeep(name, 1, 0) + "@" + domain
+	})
+}
+
+// RegisterMasker 注册脱敏方式，已存在时覆盖
+func RegisterMasker(name string, fn func(value string) string) {
+	maskers.Store(name, fn)
+}
+
+// MaskKeep 保留前 head 位和后 tail 位，其余替换为*，长度不足时至少遮盖一位
+func MaskKeep(value string, head int, tail int) string {
+	runes := []rune(value)
+	n := len(runes)
+	if n == 0 {
+		return value
+	}
+	if head+tail >= n {
+		head = min(head, n-1)
+		tail = max(0, n-1-head)
+	}
+	return string(runes[:head]) + strings.Repeat("*", n-head-tail) + string(runes[n-tail:])
+}
+
+// MaskText 按脱敏方式处理文本
+func MaskText(value string, mode string) string {
+	if value == "" {
+		return value
+	}
+	if keep, ok := strings.CutPrefix(mode, "custom="); ok {
+		head, tail, _ := strings.Cut(keep, ",")
+		return MaskKeep(value, ToInt(strings.TrimSpace(head)), ToInt(strings.TrimSpace(tail)))
+	}
+	fn, ok := maskers.Load(mode)
+	if !ok {
+		panic(fmt.Sprintf("脱敏方式不存在：%v", mode))
+	}
+	return fn.(func(string) string)(value)
+}
+
+// Mask 按当前用户角色对数据脱敏，返回脱敏后的副本
+func (c *Context) Mask(data any) any {
+	if data == nil {
+		return nil
+	}
+	m := &masker{ctx: c}
+	result := m.value(reflect.ValueOf(data))
+	if len(m.unmasked) > 0 {
+		fields := make([]string, 0, len(m.unmasked))
+		for field := range m.unmasked {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		LogInfo(fmt.Sprintf("[明文访问]用户：%v(%v)，接口：%v %v，字段：%v，次数：%d",
+			c.GetAccName(), c.GetAccId(), c.Request.Method, c.Request.URL.Path, strings.Join(fields, ","), m.count))
+	}
+	return result.Interface()
+}
+
+type masker struct {
+	ctx      *Context
+	unmasked map[string]struct{}
+	count    int
+}
+
+// canUnmask 当前用户是否可以查看字段明文
+func (s *masker) canUnmask(field reflect.StructField) bool {
+	if s.ctx == nil || s.ctx.Context == nil {
+		return false
+	}
+	if len(UnmaskRoleTags) > 0 && s.ctx.VerifyRoleTags(UnmaskRoleTags...) {
+		return true
+	}
+	if tags := field.Tag.Get("unmask"); tags != "" {
+		return s.ctx.VerifyRoleTags(strings.Split(tags, ",")...)
+	}
+	return false
+}
+
+func (s *masker) value(v reflect.Value) reflect.Value {
+	if !v.IsValid() || !needMask(v.Type()) {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		elem := s.value(v.Elem())
+		ptr := reflect.New(v.Type().Elem())
+		ptr.Elem().Set(elem)
+		return ptr
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		result := reflect.New(v.Type()).Elem()
+		result.Set(s.value(v.Elem()))
+		return result
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		result := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(s.value(v.Index(i)))
+		}
+		return result
+	case reflect.Array:
+		result := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(s.value(v.Index(i)))
+		}
+		return result
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		result := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), s.value(iter.Value()))
+		}
+		return result
+	case reflect.Struct:
+		return s.structValue(v)
+	}
+	return v
+}
+
+func (s *masker) structValue(v reflect.Value) reflect.Value {
+	t := v.Type()
+	result := reflect.New(t).Elem()
+	result.Set(v)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := result.Field(i)
+		mode := field.Tag.Get("mask")
+		if mode == "" || mode == "-" {
+			fv.Set(s.value(fv))
+			continue
+		}
+		if s.canUnmask(field) {
+			if s.unmasked == nil {
+				s.unmasked = map[string]struct{}{}
+			}
+			s.unmasked[t.Name()+"."+field.Name] = struct{}{}
+			s.count++
+			continue
+		}
+		s.maskField(fv, mode)
+	}
+	return result
+}
+
+// maskField 字段脱敏，支持字符串类型及其指针
+func (s *masker) maskField(fv reflect.Value, mode string) {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(MaskText(fv.String(), mode))
+	case reflect.Ptr:
+		if fv.IsNil() || fv.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		ptr := reflect.New(fv.Type().Elem())
+		ptr.Elem().SetString(MaskText(fv.Elem().String(), mode))
+		fv.Set(ptr)
+	}
+}
+
+// needMask 类型中是否可能包含需要脱敏的字段，结果按类型缓存
+func needMask(t reflect.Type) bool {
+	if cached, ok := maskTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	result := hasMaskField(t, map[reflect.Type]bool{})
+	maskTypes.Store(t, result)
+	return result
+}
+
+// hasMaskField visiting 用于跳过递归类型，环路上的字段会在其他路径中检查到
+func hasMaskField(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem().Kind() != reflect.Uint8 && hasMaskField(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if mode := field.Tag.Get("mask"); mode != "" && mode != "-" {
+				return true
+			}
+			if hasMaskField(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：接口请求指标，在 router.BaseMiddle 中请求处理完成后记录
+// *****************************************************************************
+
+package vingo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lgdzz/vingo-utils-v3/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounter("http_requests_total", "接口请求数", "route", "method", "status", "error_type")
+	httpDuration = metrics.NewHistogram("http_request_duration_seconds", "接口耗时", nil, "route", "method", "error_type")
+)
+
+// ObserveRequest 记录请求指标，状态码取实际响应，错误类型取 Response 的输出
+// 路由按注册的路径统计，未匹配的路由合并为 unmatched，避免标签过多
+func (c *Context) ObserveRequest(startTime time.Time) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	errorType := "none"
+	if value, ok := c.Get("responseData"); ok {
+		if d, ok := value.(*ResponseData); ok && d.Error != 0 {
+			errorType = d.ErrorType
+			if errorType == "" {
+				errorType = "error"
+			}
+		}
+	}
+	method := c.Request.Method
+	httpRequests.Inc(route, method, strconv.Itoa(c.Writer.Status()), errorType)
+	if !startTime.IsZero() {
+		httpDuration.Observe(time.Since(startTime).Seconds(), route, method, errorType)
+	}
+}
@@ -0,0 +1,127 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：优雅停机
+//
+// 组件创建时注册关闭方法，服务收到退出信号后先停止接收请求，再按阶段顺序关闭：
+// 业务组件 -> 消费者（消息队列、kafka消费）-> 生产者 -> 存储（数据库、redis）-> 日志
+// 同一阶段内后注册的先关闭
+//
+//	vingo.RegisterShutdown("定时任务", vingo.ShutdownApp, func(ctx context.Context) error {
+//		cron.Stop()
+//		return nil
+//	})
+// *****************************************************************************
+
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ShutdownApp      = 0  // 业务组件
+	ShutdownConsumer = 10 // 消费者，处理完当前消息后停止
+	ShutdownProducer = 20 // 生产者
+	ShutdownStorage  = 30 // 数据库、redis
+	ShutdownLog      = 40 // 日志，最后写入
+)
+
+type shutdownItem struct {
+	name    string
+	stage   int
+	seq     int
+	handler func(ctx context.Context) error
+}
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownItems []shutdownItem
+	shutdownSeq   int
+	shutdownOnce  sync.Once
+)
+
+// RegisterShutdown 注册关闭方法，stage 越小越先关闭
+func RegisterShutdown(name string, stage int, handler func(ctx context.Context) error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownSeq++
+	shutdownItems = append(shutdownItems, shutdownItem{name: name, stage: stage, seq: shutdownSeq, handler: handler})
+}
+
+// ShutdownGrace ctx 超时后每个关闭方法的等待时间
+var ShutdownGrace = time.Second
+
+// Shutdown 按顺序关闭已注册的组件，只执行一次
+// 每个关闭方法最多等待到 ctx 超时，超时后剩余组件仍按顺序关闭，每个最多等待 ShutdownGrace；
+// 日志阶段始终等待完成，保证停机过程中的日志写入
+func Shutdown(ctx context.Context) {
+	shutdownOnce.Do(func() {
+		shutdownMu.Lock()
+		items := append([]shutdownItem{}, shutdownItems...)
+		shutdownMu.Unlock()
+
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].stage != items[j].stage {
+				return items[i].stage < items[j].stage
+			}
+			return items[i].seq > items[j].seq
+		})
+
+		for _, item := range items {
+			start := time.Now()
+			err := runShutdown(ctx, item)
+			if err != nil {
+				fmt.Println(fmt.Sprintf("[停机]%v关闭失败：%v", item.name, err))
+				LogError(fmt.Sprintf("[停机]%v关闭失败：%v", item.name, err))
+			} else {
+				fmt.Println(fmt.Sprintf("[停机]%v已关闭，耗时：%v", item.name, time.Since(start)))
+			}
+		}
+	})
+}
+
+// runShutdown 在协程中执行关闭方法，关闭方法忽略 ctx 时也不会阻塞停机
+// ctx 已超时时关闭方法收到新的 ShutdownGrace 超时 ctx，避免拿到已取消的 ctx 直接放弃关闭
+func runShutdown(ctx context.Context, item shutdownItem) error {
+	expired := ctx.Err() != nil
+	if expired {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), ShutdownGrace)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("%v", r)
+			}
+		}()
+		done <- item.handler(ctx)
+	}()
+	if item.stage >= ShutdownLog {
+		return <-done
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if expired {
+			return fmt.Errorf("等待超时，不再等待：%w", ctx.Err())
+		}
+	}
+	// 超时后再等待 ShutdownGrace，减少与下一阶段（如关闭数据库）同时执行的情况
+	timer := time.NewTimer(ShutdownGrace)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("等待超时，不再等待：%w", ctx.Err())
+	}
+}
@@ -115,6 +115,7 @@ func RandomNumber(length int) string {
 }
 
 // OrderNo 生成按时间+随机数的单号
+// 多实例部署时建议使用 idgen.NextSeq，无需查库校验且不会冲突
 func OrderNo(length int, check func(string) bool) string {
 	if length <= 14 {
 		panic("编号长度不少于15位")
@@ -128,6 +129,7 @@ func OrderNo(length int, check func(string) bool) string {
 }
 
 // OrderNoPrefix 生成按时间+随机数的单号
+// 多实例部署时建议使用 idgen.NextSeq，无需查库校验且不会冲突
 func OrderNoPrefix(prefix string, length int, check func(string) bool) string {
 	if length <= 14 {
 		panic("编号长度不少于15位")
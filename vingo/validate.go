@@ -0,0 +1,236 @@
+// *****************************************************************************
+// 作者: lgdz
+// 创建时间: 2026/10/19
+// 描述：参数校验错误翻译
+//
+// 校验失败时返回字段列表，字段名使用 json 标签（嵌套字段如 items[0].name），提示中的字段名称取 label 标签：
+//
+//	type Input struct {
+//		Phone ctype.Phone `json:"phone" binding:"required,phone" label:"手机号"`
+//	}
+//
+// 响应：{"error":1,"message":"手机号为必填字段","errors":[{"field":"phone","label":"手机号","tag":"required","message":"手机号为必填字段"}]}
+// 语言按请求头 Accept-Language 选择，支持 zh、en，默认 ValidLocale
+// *****************************************************************************
+
+package vingo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// ValidLocale 默认提示语言
+var ValidLocale = "zh"
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，与请求参数一致
+	Label   string `json:"label"`   // 字段名称
+	Tag     string `json:"tag"`     // 校验规则
+	Message string `json:"message"` // 提示
+}
+
+// ValidationError 参数校验错误
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		messages = append(messages, item.Message)
+	}
+	return strings.Join(messages, "；")
+}
+
+var (
+	validEngines []validEngine
+	validMu      sync.Mutex
+)
+
+// validEngine 校验实例和对应的翻译器，翻译内容注册在翻译器上，每个实例需单独的翻译器
+type validEngine struct {
+	v   *validator.Validate
+	uni *ut.UniversalTranslator
+}
+
+// setupValidator 注册字段名称和翻译，Valid（validate 标签）和 gin 的 binding 标签使用相同配置
+func setupValidator(v *validator.Validate) {
+	uni := ut.New(zh.New(), zh.New(), en.New())
+	v.RegisterTagNameFunc(fieldLabel)
+	zhTrans, _ := uni.GetTranslator("zh")
+	enTrans, _ := uni.GetTranslator("en")
+	if err := zhTranslations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		panic(err.Error())
+	}
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		panic(err.Error())
+	}
+	validEngines = append(validEngines, validEngine{v: v, uni: uni})
+}
+
+func initValidator() {
+	setupValidator(Valid)
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		setupValidator(engine)
+	}
+}
+
+// fieldLabel 提示中的字段名称：label 标签 > json 标签 > 字段名
+func fieldLabel(field reflect.StructField) string {
+	if label := field.Tag.Get("label"); label != "" {
+		return label
+	}
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// RegisterValidation 注册自定义校验规则，messages 为各语言的提示，{0}为字段名称，{1}为规则参数
+//
+//	vingo.RegisterValidation("phone", fn, map[string]string{"zh": "{0}格式不正确", "en": "{0} must be a valid phone number"})
+func RegisterValidation(tag string, fn validator.Func, messages map[string]string) {
+	RegisterValidationFunc(tag, fn, func(locale string, fe validator.FieldError) string {
+		message, ok := messages[locale]
+		if !ok {
+			return fe.Error()
+		}
+		return strings.NewReplacer("{0}", fe.Field(), "{1}", fe.Param()).Replace(message)
+	})
+}
+
+// RegisterValidationFunc 注册自定义校验规则，提示需要按参数变化时使用，locale 为 zh 或 en
+func RegisterValidationFunc(tag string, fn validator.Func, translate func(locale string, fe validator.FieldError) string) {
+	validMu.Lock()
+	defer validMu.Unlock()
+	for _, engine := range validEngines {
+		if err := engine.v.RegisterValidation(tag, fn); err != nil {
+			panic(err.Error())
+		}
+		for _, locale := range []string{"zh", "en"} {
+			trans, _ := engine.uni.GetTranslator(locale)
+			_ = engine.v.RegisterTranslation(tag, trans, func(t ut.Translator) error {
+				return nil
+			}, func(t ut.Translator, fe validator.FieldError) string {
+				return translate(t.Locale(), fe)
+			})
+		}
+	}
+}
+
+// locale 按请求头 Accept-Language 选择
+func (c *Context) locale() string {
+	if c != nil && c.Context != nil && c.Request != nil {
+		for _, item := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+			item = strings.ToLower(strings.TrimSpace(strings.Split(item, ";")[0]))
+			if strings.HasPrefix(item, "zh") {
+				return "zh"
+			}
+			if strings.HasPrefix(item, "en") {
+				return "en"
+			}
+		}
+	}
+	return ValidLocale
+}
+
+// translate 错误来自 Valid 或 gin 的校验实例，使用产生错误的实例对应的翻译器
+func translate(fe validator.FieldError, locale string) string {
+	for _, engine := range validEngines {
+		trans, _ := engine.uni.GetTranslator(locale)
+		if message := fe.Translate(trans); message != fe.Error() {
+			return message
+		}
+	}
+	return fe.Error()
+}
+
+// ValidationErrors 将校验错误转换为 *ValidationError，t 为被校验的类型，用于生成字段路径；其他错误原样返回
+func (c *Context) ValidationErrors(err error, t reflect.Type) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	locale := c.locale()
+	result := &ValidationError{Errors: make([]FieldError, 0, len(errs))}
+	for _, fe := range errs {
+		result.Errors = append(result.Errors, FieldError{
+			Field:   fieldPath(t, fe.StructNamespace()),
+			Label:   fe.Field(),
+			Tag:     fe.Tag(),
+			Message: translate(fe, locale),
+		})
+	}
+	return result
+}
+
+// validationPanic 校验错误按字段返回，其他错误保持原有提示
+func (c *Context) validationPanic(err error, t reflect.Type) {
+	if v, ok := c.ValidationErrors(err, t).(*ValidationError); ok {
+		panic(v)
+	}
+	panic(err.Error())
+}
+
+// fieldPath 将 Input.Items[0].Name 转换为 json 路径 items[0].name
+func fieldPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	paths := make([]string, 0, len(parts))
+	for _, part := range parts {
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			paths = append(paths, name+index)
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			paths = append(paths, name+index)
+			t = nil
+			continue
+		}
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "" || jsonName == "-" {
+			jsonName = strings.Split(field.Tag.Get("form"), ",")[0]
+		}
+		t = field.Type
+		if jsonName == "" || jsonName == "-" {
+			if field.Anonymous && index == "" {
+				// 匿名嵌入的字段在 JSON 中展开
+				continue
+			}
+			jsonName = field.Name
+		}
+		paths = append(paths, jsonName+index)
+		if index != "" {
+			// 下标之后是元素类型
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+				t = t.Elem()
+			}
+		}
+	}
+	return strings.Join(paths, ".")
+}